@@ -0,0 +1,24 @@
+package game
+
+import (
+	"time"
+)
+
+// Clock 遊戲使用的時間來源，所有球與狀態的時間戳都經由此介面
+type Clock interface {
+	// Now 回傳當前時間
+	Now() time.Time
+}
+
+// systemClock 使用系統時間的時間來源，作為正式環境的預設值
+type systemClock struct{}
+
+// NewSystemClock 創建使用系統時間的時間來源
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+// Now 回傳當前系統時間
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// countdownTickInterval 倒數通知的間隔
+const countdownTickInterval = time.Second
+
+// Countdown 狀態倒數通知，剩餘秒數為 0 時控制器會自動進入下一個狀態
+type Countdown struct {
+	GameID    string    `json:"gameId"`
+	State     GameState `json:"state"`
+	NextState GameState `json:"nextState"`
+	Remaining int       `json:"remaining"` // 剩餘秒數
+}
+
+// SetCountdownListener 設置倒數通知監聽器，監聽器在倒數協程中調用，不持有控制器的鎖
+func (dfc *DataFlowController) SetCountdownListener(listener func(Countdown)) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	dfc.countdownListener = listener
+}
+
+// startCountdownLocked 開始當前狀態的倒數，每秒通知剩餘秒數，倒數結束後轉換到 next；
+// 截止時間與剩餘時間都以控制器的時間來源計算，調用者必須持有寫鎖
+func (dfc *DataFlowController) startCountdownLocked(duration time.Duration, next GameState) {
+	dfc.stopCountdownLocked()
+
+	stop := make(chan struct{})
+	dfc.countdownStop = stop
+
+	state := dfc.currentState
+	gameID := dfc.currentGameID
+	clock := dfc.clock
+	deadline := clock.Now().Add(duration)
+	tick := dfc.countdownTick
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			remaining := int(math.Ceil(deadline.Sub(clock.Now()).Seconds()))
+			if remaining < 0 {
+				remaining = 0
+			}
+
+			dfc.mu.RLock()
+			listener := dfc.countdownListener
+			active := dfc.countdownStop == stop
+			dfc.mu.RUnlock()
+			if !active {
+				return
+			}
+			if listener != nil {
+				listener(Countdown{GameID: gameID, State: state, NextState: next, Remaining: remaining})
+			}
+
+			if remaining == 0 {
+				dfc.finishCountdown(stop, next)
+				return
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// finishCountdown 倒數結束後自動轉換狀態，倒數已被取消或替換時不做任何事
+func (dfc *DataFlowController) finishCountdown(stop chan struct{}, next GameState) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	if dfc.countdownStop != stop {
+		return
+	}
+	dfc.countdownStop = nil
+
+	if err := dfc.changeStateLocked(next); err != nil {
+		log.Printf("遊戲 %s 倒數結束後自動轉換到 %s 失敗: %v", dfc.currentGameID, next, err)
+		return
+	}
+	log.Printf("遊戲 %s 倒數結束，已自動轉換到 %s", dfc.currentGameID, next)
+}
+
+// stopCountdownLocked 取消進行中的倒數，調用者必須持有寫鎖
+func (dfc *DataFlowController) stopCountdownLocked() {
+	if dfc.countdownStop != nil {
+		close(dfc.countdownStop)
+		dfc.countdownStop = nil
+	}
+}
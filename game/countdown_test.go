@@ -0,0 +1,118 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// newCountdownController 創建投注倒數使用極短檢查間隔的控制器，倒數通知寫入返回的通道
+func newCountdownController(t *testing.T, bettingDuration time.Duration) (*DataFlowController, *fakeClock, <-chan Countdown) {
+	t.Helper()
+
+	dfc, clock := newTestController(t, ControllerConfig{BettingDuration: bettingDuration})
+	dfc.countdownTick = time.Millisecond
+	t.Cleanup(func() {
+		dfc.mu.Lock()
+		defer dfc.mu.Unlock()
+		dfc.stopCountdownLocked()
+	})
+
+	countdowns := make(chan Countdown, 1024)
+	dfc.SetCountdownListener(func(countdown Countdown) {
+		select {
+		case countdowns <- countdown:
+		default:
+		}
+	})
+	return dfc, clock, countdowns
+}
+
+// waitForCountdown 等待剩餘秒數為 remaining 的倒數通知
+func waitForCountdown(t *testing.T, countdowns <-chan Countdown, remaining int) Countdown {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case countdown := <-countdowns:
+			if countdown.Remaining == remaining {
+				return countdown
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for countdown with %d seconds remaining", remaining)
+		}
+	}
+}
+
+// waitForState 等待控制器進入指定狀態
+func waitForState(t *testing.T, dfc *DataFlowController, state GameState) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for dfc.GetCurrentState() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", dfc.GetCurrentState(), state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCountdownUsesControllerClock(t *testing.T) {
+	dfc, clock, countdowns := newCountdownController(t, 3*time.Second)
+	moveTo(t, dfc, StateReady, StateBetting)
+
+	first := waitForCountdown(t, countdowns, 3)
+	if first.State != StateBetting || first.NextState != StateDrawing {
+		t.Fatalf("countdown = %+v, want BETTING -> DRAWING", first)
+	}
+
+	// 時鐘未前進時剩餘秒數不變，也不會自動轉換狀態
+	time.Sleep(20 * time.Millisecond)
+	for len(countdowns) > 0 {
+		if countdown := <-countdowns; countdown.Remaining != 3 {
+			t.Fatalf("remaining = %d without advancing the clock, want 3", countdown.Remaining)
+		}
+	}
+	if state := dfc.GetCurrentState(); state != StateBetting {
+		t.Fatalf("state = %s without advancing the clock, want %s", state, StateBetting)
+	}
+
+	clock.Advance(time.Second)
+	waitForCountdown(t, countdowns, 2)
+}
+
+func TestCountdownAdvancesToDrawing(t *testing.T) {
+	dfc, clock, countdowns := newCountdownController(t, 3*time.Second)
+	moveTo(t, dfc, StateReady, StateBetting)
+
+	// 每前進一秒剩餘秒數遞減，歸零後自動開始抽球
+	for remaining := 3; remaining > 0; remaining-- {
+		waitForCountdown(t, countdowns, remaining)
+		if state := dfc.GetCurrentState(); state != StateBetting {
+			t.Fatalf("state = %s with %d seconds remaining, want %s", state, remaining, StateBetting)
+		}
+		clock.Advance(time.Second)
+	}
+	waitForCountdown(t, countdowns, 0)
+	waitForState(t, dfc, StateDrawing)
+}
+
+func TestCountdownCancelledByManualTransition(t *testing.T) {
+	dfc, clock, countdowns := newCountdownController(t, 3*time.Second)
+	moveTo(t, dfc, StateReady, StateBetting)
+	waitForCountdown(t, countdowns, 3)
+
+	// 荷官提前開始抽球後，原倒數到期不應再轉換狀態
+	moveTo(t, dfc, StateDrawing)
+	clock.Advance(3 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+
+	if state := dfc.GetCurrentState(); state != StateDrawing {
+		t.Fatalf("state = %s, want %s", state, StateDrawing)
+	}
+	for len(countdowns) > 0 {
+		if countdown := <-countdowns; countdown.Remaining == 0 {
+			t.Fatal("cancelled countdown reached zero")
+		}
+	}
+}
@@ -2,17 +2,12 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
-// 在 package 級別初始化隨機數生成器
-func init() {
-	// 在 Go 1.20+ 中不需要手動設置種子，但為了向後兼容
-	rand.Seed(time.Now().UnixNano())
-}
-
 // GameState 代表遊戲的不同狀態
 type GameState string
 
@@ -38,6 +33,89 @@ const (
 	StateCompleted       GameState = "COMPLETED"         // 遊戲完成狀態
 )
 
+// IsTerminal 判斷是否為終止狀態，終止狀態不允許再轉換
+func (s GameState) IsTerminal() bool {
+	return s == StateCompleted
+}
+
+// GameStatus 代表遊戲的整體進度，比 GameState 更粗略，供客戶端判斷遊戲是否進行中
+type GameStatus string
+
+const (
+	GameStatusCreated    GameStatus = "CREATED"     // 已建立，尚未開始投注
+	GameStatusInProgress GameStatus = "IN_PROGRESS" // 進行中
+	GameStatusCompleted  GameStatus = "COMPLETED"   // 已完成
+)
+
+// StatusForState 由遊戲狀態推導整體進度
+func StatusForState(state GameState) GameStatus {
+	switch state {
+	case StateInitial, StateAgent, StateStandby, StateReady:
+		return GameStatusCreated
+	case StateResult, StateJPResult, StateCompleted:
+		// 進入結算後本局不再有投注或抽球
+		return GameStatusCompleted
+	default:
+		return GameStatusInProgress
+	}
+}
+
+// transitions 定義合法的狀態轉換，鍵為當前狀態，值為允許的下一個狀態
+var transitions = map[GameState][]GameState{
+	StateInitial:   {StateStandby, StateReady, StateAgent},
+	StateAgent:     {StateReady},
+	StateStandby:   {StateBetting},
+	StateReady:     {StateBetting},
+	StateBetting:   {StateDrawing},
+	StateDrawing:   {StateExtraBet, StateJPStandby},
+	StateExtraBet:  {StateExtraDraw},
+	StateExtraDraw: {StateResult},
+	StateResult:    {StateStandby, StateCompleted},
+	StateJPStandby: {StateJPBetting},
+	StateJPBetting: {StateJPDrawing},
+	StateJPDrawing: {StateJPResult},
+	StateJPResult:  {StateStandby, StateCompleted},
+}
+
+// CanTransition 檢查是否允許從 from 轉換到 to
+func CanTransition(from, to GameState) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions 返回指定狀態允許轉換到的下一個狀態
+func AllowedTransitions(from GameState) []GameState {
+	allowed := make([]GameState, len(transitions[from]))
+	copy(allowed, transitions[from])
+	return allowed
+}
+
+// StateTransition 記錄遊戲進入某個狀態的時間
+type StateTransition struct {
+	State     GameState `json:"state"`
+	EnteredAt time.Time `json:"enteredAt"`
+}
+
+// BallType 代表球的類型
+type BallType string
+
+const (
+	BallTypeRegular BallType = "REGULAR" // 主遊戲球
+	BallTypeExtra   BallType = "EXTRA"   // 額外球
+)
+
+// ExtraBallSide 代表額外球的投注側邊
+type ExtraBallSide string
+
+const (
+	ExtraBallSideLeft  ExtraBallSide = "LEFT"  // 左側
+	ExtraBallSideRight ExtraBallSide = "RIGHT" // 右側
+)
+
 // DrawResult 代表抽球的結果
 type DrawResult struct {
 	BallNumber int       `json:"ball_number"`
@@ -50,42 +128,150 @@ type DataFlowController struct {
 	mu sync.RWMutex
 
 	// 遊戲狀態管理
-	currentState GameState   // 當前遊戲狀態
-	stateHistory []GameState // 狀態歷史記錄
+	currentState GameState         // 當前遊戲狀態
+	stateHistory []StateTransition // 本局進入各狀態的記錄，最後一筆為當前狀態
 
 	// 球池管理
 	sourceBalls []int        // 原始球池 (例如: 1-75)
 	drawnBalls  []DrawResult // 已抽出的球
 	extraBalls  []DrawResult // 額外球
+	jpBalls     []DrawResult // JP球
 
 	// 遊戲設定
-	totalBalls    int // 總球數
-	mainDrawCount int // 主遊戲抽球數
-	maxExtraBalls int // 最大額外球數
+	totalBalls       int // 總球數
+	mainDrawCount    int // 主遊戲抽球數
+	jpDrawCount      int // JP遊戲抽球數
+	maxExtraBalls    int // 最大額外球數
+	luckyNumberCount int // 幸運號碼數量
 
 	// 其他設定
 	jpTriggerNumbers []int  // JP觸發號碼
 	currentGameID    string // 當前遊戲ID
 	isJPTriggered    bool   // 是否觸發JP
+	jpOverride       *bool  // 荷官手動設定的JP觸發狀態，設定後優先於依抽球結果的判斷，nil 表示未設定
+
+	selectedExtraBallSide ExtraBallSide // 荷官選定的額外球側邊，未選定為空
+
+	// JP獎金
+	jackpotBaseAmount float64 // JP基礎獎金，中獎後重置為此金額
+	jackpotIncrement  float64 // 每局未中JP時累積的獎金，0 表示固定金額
+	jackpotAmount     float64 // 目前JP獎金
+	jackpotWinner     string  // 本局JP獲勝者ID，未通知為空
+
+	random RandomBallSource // 抽球隨機數來源
+	clock  Clock            // 時間戳來源
+
+	// 自動重置
+	autoResetDelay time.Duration // 進入結算狀態後自動回到待機的延遲，0 表示停用
+	autoResetTimer *time.Timer   // 等待中的自動重置計時器
+
+	changeListener func(*Snapshot) // 遊戲變更監聽器，用於持久化
+
+	// 投注倒數
+	bettingDuration   time.Duration   // 投注階段時長，結束後自動開始抽球，0 表示停用
+	countdownTick     time.Duration   // 倒數檢查與通知的間隔
+	countdownStop     chan struct{}   // 進行中倒數的停止通道
+	countdownListener func(Countdown) // 倒數通知監聽器
+}
+
+// ControllerConfig 遊戲流程控制器設定，未設置的欄位使用預設值
+type ControllerConfig struct {
+	LuckyNumberCount int              // 幸運號碼數量，預設7個
+	TotalBalls       int              // 球池總球數，預設75球
+	MainDrawCount    int              // 主遊戲抽球數，預設30球
+	JPDrawCount      int              // JP遊戲抽球數，預設30球
+	MaxExtraBalls    int              // 最大額外球數，預設3顆
+	RandomSource     RandomBallSource // 抽球隨機數來源，預設使用 crypto/rand
+	Clock            Clock            // 時間戳來源，預設使用系統時間
+	AutoResetDelay   time.Duration    // 結算後自動開始下一局的延遲，預設0（停用）
+	BettingDuration  time.Duration    // 投注階段時長，結束後自動開始抽球，預設0（停用）
+	JackpotAmount    float64          // JP基礎獎金，預設500000
+	JackpotIncrement float64          // 每局未中JP時累積的獎金，預設0（固定金額）
 }
 
-// NewDataFlowController 創建一個新的DataFlowController實例
-func NewDataFlowController() *DataFlowController {
+// NewDataFlowController 創建一個新的DataFlowController實例，球數設定不一致時返回錯誤
+func NewDataFlowController(cfg *ControllerConfig) (*DataFlowController, error) {
 	controller := &DataFlowController{
 		currentState:     StateAgent,
-		stateHistory:     make([]GameState, 0),
 		sourceBalls:      make([]int, 0),
 		drawnBalls:       make([]DrawResult, 0),
 		extraBalls:       make([]DrawResult, 0),
+		jpBalls:          make([]DrawResult, 0),
 		totalBalls:       75, // 預設75球
 		mainDrawCount:    30, // 預設主遊戲抽30球
+		jpDrawCount:      30, // 預設JP遊戲抽30球
 		maxExtraBalls:    3,  // 預設最多3顆額外球
+		luckyNumberCount: 7,  // 預設七個幸運號碼
 		jpTriggerNumbers: make([]int, 0),
 		isJPTriggered:    false,
+		random:           NewCryptoBallSource(),
+		clock:            NewSystemClock(),
+
+		jackpotBaseAmount: 500000, // 預設JP獎金
+		countdownTick:     countdownTickInterval,
 	}
 
+	if cfg != nil {
+		if cfg.LuckyNumberCount > 0 {
+			controller.luckyNumberCount = cfg.LuckyNumberCount
+		}
+		if cfg.TotalBalls > 0 {
+			controller.totalBalls = cfg.TotalBalls
+		}
+		if cfg.MainDrawCount > 0 {
+			controller.mainDrawCount = cfg.MainDrawCount
+		}
+		if cfg.JPDrawCount > 0 {
+			controller.jpDrawCount = cfg.JPDrawCount
+		}
+		if cfg.MaxExtraBalls > 0 {
+			controller.maxExtraBalls = cfg.MaxExtraBalls
+		}
+		if cfg.RandomSource != nil {
+			controller.random = cfg.RandomSource
+		}
+		if cfg.Clock != nil {
+			controller.clock = cfg.Clock
+		}
+		if cfg.AutoResetDelay > 0 {
+			controller.autoResetDelay = cfg.AutoResetDelay
+		}
+		if cfg.BettingDuration > 0 {
+			controller.bettingDuration = cfg.BettingDuration
+		}
+		if cfg.JackpotAmount > 0 {
+			controller.jackpotBaseAmount = cfg.JackpotAmount
+		}
+		if cfg.JackpotIncrement > 0 {
+			controller.jackpotIncrement = cfg.JackpotIncrement
+		}
+	}
+
+	if err := controller.validateBallCounts(); err != nil {
+		return nil, err
+	}
+	controller.jackpotAmount = controller.jackpotBaseAmount
+	controller.stateHistory = []StateTransition{{State: StateAgent, EnteredAt: controller.clock.Now()}}
+
 	controller.initializeBallPool()
-	return controller
+	return controller, nil
+}
+
+// validateBallCounts 在啟動時檢查球數設定，確保一局內主球與額外球不會耗盡球池
+func (dfc *DataFlowController) validateBallCounts() error {
+	if dfc.mainDrawCount > dfc.totalBalls {
+		return newError(ErrInvalidArgument, "main draw count %d exceeds ball pool size %d", dfc.mainDrawCount, dfc.totalBalls)
+	}
+	if dfc.mainDrawCount+dfc.maxExtraBalls > dfc.totalBalls {
+		return newError(ErrInvalidArgument, "main draw count %d plus max extra balls %d exceeds ball pool size %d", dfc.mainDrawCount, dfc.maxExtraBalls, dfc.totalBalls)
+	}
+	if dfc.jpDrawCount > dfc.totalBalls {
+		return newError(ErrInvalidArgument, "JP draw count %d exceeds ball pool size %d", dfc.jpDrawCount, dfc.totalBalls)
+	}
+	if dfc.luckyNumberCount > dfc.totalBalls {
+		return newError(ErrInvalidArgument, "lucky number count %d exceeds ball pool size %d", dfc.luckyNumberCount, dfc.totalBalls)
+	}
+	return nil
 }
 
 // initializeBallPool 初始化球池
@@ -104,22 +290,154 @@ func (dfc *DataFlowController) ChangeState(newState GameState) error {
 	dfc.mu.Lock()
 	defer dfc.mu.Unlock()
 
+	return dfc.changeStateLocked(newState)
+}
+
+// ChangeStateAndGetStatus 改變遊戲狀態並在同一把鎖內返回轉換後的遊戲狀態快照，
+// 避免先轉換再查詢時被其他操作插入
+func (dfc *DataFlowController) ChangeStateAndGetStatus(newState GameState) (*GameStatusResponse, error) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	if err := dfc.changeStateLocked(newState); err != nil {
+		return nil, err
+	}
+
+	return dfc.buildGameStatusLocked(), nil
+}
+
+// changeStateLocked 執行狀態轉換，調用者必須持有寫鎖
+func (dfc *DataFlowController) changeStateLocked(newState GameState) error {
+	// 已完成的遊戲不允許再推進
+	if dfc.currentState.IsTerminal() {
+		return newError(ErrInvalidTransition, "game %s is already in terminal state %s, cannot transition to %s", dfc.currentGameID, dfc.currentState, newState)
+	}
+
 	// 檢查狀態轉換是否合法
-	if !dfc.isValidStateTransition(dfc.currentState, newState) {
-		return fmt.Errorf("invalid state transition from %s to %s", dfc.currentState, newState)
+	if !CanTransition(dfc.currentState, newState) {
+		return newError(ErrInvalidTransition, "invalid state transition from %s to %s, allowed next states: %v", dfc.currentState, newState, AllowedTransitions(dfc.currentState))
 	}
 
-	dfc.stateHistory = append(dfc.stateHistory, dfc.currentState)
+	previousState := dfc.currentState
 	dfc.currentState = newState
+	dfc.stateHistory = append(dfc.stateHistory, StateTransition{State: newState, EnteredAt: dfc.clock.Now()})
+	dfc.updateJackpotLocked(previousState, newState)
+
+	// 任何狀態轉換（包括手動開始下一局）都會取消等待中的自動重置與倒數
+	dfc.stopAutoResetLocked()
+	dfc.stopCountdownLocked()
 
 	// 如果進入新遊戲，重置相關數據
 	if newState == StateStandby {
 		dfc.resetGame()
 	}
 
+	// 進入結算狀態後排程自動開始下一局
+	if newState == StateResult || newState == StateJPResult {
+		dfc.scheduleAutoResetLocked()
+	}
+
+	// 進入投注階段後開始倒數，結束時自動開始抽球
+	if newState == StateBetting && dfc.bettingDuration > 0 {
+		dfc.startCountdownLocked(dfc.bettingDuration, StateDrawing)
+	}
+
+	dfc.notifyChangeLocked()
 	return nil
 }
 
+// updateJackpotLocked 依狀態轉換更新JP獎金：一般結算時累積，JP開出後離開結算（下一局或結束）時重置為基礎金額，
+// 調用者必須持有寫鎖
+func (dfc *DataFlowController) updateJackpotLocked(from, to GameState) {
+	switch {
+	case to == StateResult:
+		dfc.jackpotAmount += dfc.jackpotIncrement
+	case from == StateJPResult:
+		dfc.jackpotAmount = dfc.jackpotBaseAmount
+	}
+}
+
+// SetHasJackpot 手動設定本局是否觸發JP，僅允許在進入JP流程之前（待機至主遊戲抽球階段）
+func (dfc *DataFlowController) SetHasJackpot(hasJackpot bool) error {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	switch dfc.currentState {
+	case StateStandby, StateReady, StateBetting, StateDrawing:
+	default:
+		return newError(ErrInvalidState, "cannot change jackpot flag in current state: %s", dfc.currentState)
+	}
+
+	// 手動設定獨立保存，之後的抽球與撤銷不會覆蓋
+	dfc.jpOverride = &hasJackpot
+	dfc.isJPTriggered = hasJackpot
+	dfc.notifyChangeLocked()
+	return nil
+}
+
+// SetJackpotWinner 在JP結算階段記錄本局JP獲勝者，獎金已派發，JP獎金重置為基礎金額
+func (dfc *DataFlowController) SetJackpotWinner(winnerID string) error {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	if winnerID == "" {
+		return newError(ErrInvalidArgument, "jackpot winner id is required")
+	}
+	if !dfc.isJPTriggered {
+		return newError(ErrInvalidState, "game %s has no active jackpot", dfc.currentGameID)
+	}
+	if dfc.currentState != StateJPResult {
+		return newError(ErrInvalidState, "cannot set jackpot winner in current state: %s", dfc.currentState)
+	}
+
+	dfc.jackpotWinner = winnerID
+	dfc.jackpotAmount = dfc.jackpotBaseAmount
+	dfc.notifyChangeLocked()
+	return nil
+}
+
+// GetJackpotAmount 獲取目前JP獎金
+func (dfc *DataFlowController) GetJackpotAmount() float64 {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	return dfc.jackpotAmount
+}
+
+// scheduleAutoResetLocked 在延遲後自動轉換到待機狀態，調用者必須持有寫鎖
+func (dfc *DataFlowController) scheduleAutoResetLocked() {
+	if dfc.autoResetDelay <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(dfc.autoResetDelay, func() {
+		dfc.mu.Lock()
+		defer dfc.mu.Unlock()
+
+		// 計時器已被取消或替換
+		if dfc.autoResetTimer != timer {
+			return
+		}
+		dfc.autoResetTimer = nil
+
+		if err := dfc.changeStateLocked(StateStandby); err != nil {
+			log.Printf("自動重置遊戲 %s 失敗: %v", dfc.currentGameID, err)
+			return
+		}
+		log.Printf("遊戲已於結算 %v 後自動重置為 %s", dfc.autoResetDelay, StateStandby)
+	})
+	dfc.autoResetTimer = timer
+}
+
+// stopAutoResetLocked 取消等待中的自動重置，調用者必須持有寫鎖
+func (dfc *DataFlowController) stopAutoResetLocked() {
+	if dfc.autoResetTimer != nil {
+		dfc.autoResetTimer.Stop()
+		dfc.autoResetTimer = nil
+	}
+}
+
 // DrawBall 從球池中抽出一顆球
 func (dfc *DataFlowController) DrawBall() (*DrawResult, error) {
 	dfc.mu.Lock()
@@ -127,12 +445,17 @@ func (dfc *DataFlowController) DrawBall() (*DrawResult, error) {
 
 	// 檢查當前狀態是否允許抽球
 	if dfc.currentState != StateDrawing && dfc.currentState != StateJPDrawing {
-		return nil, fmt.Errorf("cannot draw ball in current state: %s", dfc.currentState)
+		return nil, newError(ErrInvalidState, "cannot draw ball in current state: %s", dfc.currentState)
+	}
+
+	// 主遊戲階段最多抽出 mainDrawCount 顆球
+	if dfc.currentState == StateDrawing && len(dfc.drawnBalls) >= dfc.mainDrawCount {
+		return nil, newError(ErrBallsExhausted, "main draw count %d reached", dfc.mainDrawCount)
 	}
 
 	// 檢查是否還有球可抽
 	if len(dfc.drawnBalls) >= dfc.totalBalls {
-		return nil, fmt.Errorf("no more balls available")
+		return nil, newError(ErrBallsExhausted, "no more balls available")
 	}
 
 	// 計算剩餘可抽的球
@@ -151,16 +474,16 @@ func (dfc *DataFlowController) DrawBall() (*DrawResult, error) {
 	}
 
 	if len(remainingBalls) == 0 {
-		return nil, fmt.Errorf("no more balls remaining")
+		return nil, newError(ErrBallsExhausted, "no more balls remaining")
 	}
 
 	// 隨機抽一顆球
-	selectedBall := remainingBalls[rand.Intn(len(remainingBalls))]
+	selectedBall := pickBall(dfc.random, remainingBalls)
 
 	// 創建抽球結果
 	result := DrawResult{
 		BallNumber: selectedBall,
-		DrawTime:   time.Now(),
+		DrawTime:   dfc.clock.Now(),
 		OrderIndex: len(dfc.drawnBalls) + 1,
 	}
 
@@ -171,6 +494,7 @@ func (dfc *DataFlowController) DrawBall() (*DrawResult, error) {
 		dfc.checkJPTrigger(selectedBall)
 	}
 
+	dfc.notifyChangeLocked()
 	return &result, nil
 }
 
@@ -181,12 +505,12 @@ func (dfc *DataFlowController) DrawExtraBall() (*DrawResult, error) {
 
 	// 檢查當前狀態是否允許抽額外球
 	if dfc.currentState != StateExtraDraw {
-		return nil, fmt.Errorf("cannot draw extra ball in current state: %s", dfc.currentState)
+		return nil, newError(ErrInvalidState, "cannot draw extra ball in current state: %s", dfc.currentState)
 	}
 
 	// 檢查是否超過最大額外球數
 	if len(dfc.extraBalls) >= dfc.maxExtraBalls {
-		return nil, fmt.Errorf("maximum extra balls reached")
+		return nil, newError(ErrBallsExhausted, "maximum extra balls reached")
 	}
 
 	// 計算剩餘可抽的球（主球和額外球都需要排除）
@@ -206,24 +530,141 @@ func (dfc *DataFlowController) DrawExtraBall() (*DrawResult, error) {
 	}
 
 	if len(remainingBalls) == 0 {
-		return nil, fmt.Errorf("no more balls remaining for extra draw")
+		return nil, newError(ErrBallsExhausted, "no more balls remaining for extra draw")
 	}
 
 	// 隨機抽一顆額外球
-	selectedBall := remainingBalls[rand.Intn(len(remainingBalls))]
+	selectedBall := pickBall(dfc.random, remainingBalls)
 
 	// 創建額外球結果
 	result := DrawResult{
 		BallNumber: selectedBall,
-		DrawTime:   time.Now(),
+		DrawTime:   dfc.clock.Now(),
 		OrderIndex: len(dfc.extraBalls) + 1,
 	}
 
 	dfc.extraBalls = append(dfc.extraBalls, result)
 
+	dfc.notifyChangeLocked()
+	return &result, nil
+}
+
+// DrawJPBall 在JP抽球階段抽取一顆JP球，JP球使用獨立的完整球池
+func (dfc *DataFlowController) DrawJPBall() (*DrawResult, error) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	// 檢查當前狀態是否允許抽JP球
+	if dfc.currentState != StateJPDrawing {
+		return nil, newError(ErrInvalidState, "cannot draw JP ball in current state: %s", dfc.currentState)
+	}
+
+	// JP遊戲最多抽出 jpDrawCount 顆球
+	if len(dfc.jpBalls) >= dfc.jpDrawCount {
+		return nil, newError(ErrBallsExhausted, "JP draw count %d reached", dfc.jpDrawCount)
+	}
+
+	usedBalls := make(map[int]bool, len(dfc.jpBalls))
+	for _, jp := range dfc.jpBalls {
+		usedBalls[jp.BallNumber] = true
+	}
+
+	remainingBalls := make([]int, 0)
+	for _, ball := range dfc.sourceBalls {
+		if !usedBalls[ball] {
+			remainingBalls = append(remainingBalls, ball)
+		}
+	}
+
+	if len(remainingBalls) == 0 {
+		return nil, newError(ErrBallsExhausted, "no more balls remaining for JP draw")
+	}
+
+	// 隨機抽一顆JP球
+	selectedBall := pickBall(dfc.random, remainingBalls)
+
+	result := DrawResult{
+		BallNumber: selectedBall,
+		DrawTime:   dfc.clock.Now(),
+		OrderIndex: len(dfc.jpBalls) + 1,
+	}
+
+	dfc.jpBalls = append(dfc.jpBalls, result)
+
+	dfc.notifyChangeLocked()
 	return &result, nil
 }
 
+// SelectExtraBallSide 在額外球投注階段選定額外球側邊
+func (dfc *DataFlowController) SelectExtraBallSide(side ExtraBallSide) error {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	if side != ExtraBallSideLeft && side != ExtraBallSideRight {
+		return newError(ErrInvalidArgument, "invalid extra ball side: %q, expected %s or %s", side, ExtraBallSideLeft, ExtraBallSideRight)
+	}
+
+	if dfc.currentState != StateExtraBet {
+		return newError(ErrInvalidState, "cannot select extra ball side in current state: %s", dfc.currentState)
+	}
+
+	dfc.selectedExtraBallSide = side
+	dfc.notifyChangeLocked()
+	return nil
+}
+
+// GetSelectedExtraBallSide 獲取選定的額外球側邊，未選定時返回空字串
+func (dfc *DataFlowController) GetSelectedExtraBallSide() ExtraBallSide {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	return dfc.selectedExtraBallSide
+}
+
+// UndoLastBall 撤銷指定類型最後抽出的一顆球，用於修正荷官誤操作
+func (dfc *DataFlowController) UndoLastBall(ballType BallType) (*DrawResult, error) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	switch ballType {
+	case BallTypeRegular:
+		// 已離開抽球階段的球不可撤銷
+		if dfc.currentState != StateDrawing && dfc.currentState != StateJPDrawing {
+			return nil, newError(ErrInvalidState, "cannot undo regular ball in current state: %s", dfc.currentState)
+		}
+		if len(dfc.drawnBalls) == 0 {
+			return nil, newError(ErrNothingToUndo, "no regular balls to undo")
+		}
+
+		removed := dfc.drawnBalls[len(dfc.drawnBalls)-1]
+		dfc.drawnBalls = dfc.drawnBalls[:len(dfc.drawnBalls)-1]
+
+		// 主遊戲抽球階段撤銷後需重新判斷是否仍觸發JP
+		if dfc.currentState == StateDrawing {
+			dfc.recheckJPTrigger()
+		}
+
+		dfc.notifyChangeLocked()
+		return &removed, nil
+
+	case BallTypeExtra:
+		if dfc.currentState != StateExtraDraw {
+			return nil, newError(ErrInvalidState, "cannot undo extra ball in current state: %s", dfc.currentState)
+		}
+		if len(dfc.extraBalls) == 0 {
+			return nil, newError(ErrNothingToUndo, "no extra balls to undo")
+		}
+
+		removed := dfc.extraBalls[len(dfc.extraBalls)-1]
+		dfc.extraBalls = dfc.extraBalls[:len(dfc.extraBalls)-1]
+
+		dfc.notifyChangeLocked()
+		return &removed, nil
+	}
+
+	return nil, newError(ErrInvalidArgument, "unknown ball type: %s", ballType)
+}
+
 // GetCurrentState 獲取當前遊戲狀態
 func (dfc *DataFlowController) GetCurrentState() GameState {
 	dfc.mu.RLock()
@@ -232,33 +673,90 @@ func (dfc *DataFlowController) GetCurrentState() GameState {
 	return dfc.currentState
 }
 
-// GetDrawnBalls 獲取已抽出的球
-func (dfc *DataFlowController) GetDrawnBalls() []DrawResult {
+// GetStateHistory 獲取本局的狀態轉換記錄，依進入時間排序
+func (dfc *DataFlowController) GetStateHistory() []StateTransition {
 	dfc.mu.RLock()
 	defer dfc.mu.RUnlock()
 
-	result := make([]DrawResult, len(dfc.drawnBalls))
-	copy(result, dfc.drawnBalls)
+	result := make([]StateTransition, len(dfc.stateHistory))
+	copy(result, dfc.stateHistory)
 	return result
 }
 
-// GetExtraBalls 獲取額外球
+// GetDrawnBalls 獲取已抽出的球，保證依 OrderIndex 由小到大排序
+func (dfc *DataFlowController) GetDrawnBalls() []DrawResult {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	return sortedByOrder(dfc.drawnBalls)
+}
+
+// GetExtraBalls 獲取額外球，保證依 OrderIndex 由小到大排序
 func (dfc *DataFlowController) GetExtraBalls() []DrawResult {
 	dfc.mu.RLock()
 	defer dfc.mu.RUnlock()
 
-	result := make([]DrawResult, len(dfc.extraBalls))
-	copy(result, dfc.extraBalls)
-	return result
+	return sortedByOrder(dfc.extraBalls)
 }
 
-// SetJPTriggerNumbers 設置JP觸發號碼
-func (dfc *DataFlowController) SetJPTriggerNumbers(numbers []int) {
+// GetJPBalls 獲取JP球，保證依 OrderIndex 由小到大排序
+func (dfc *DataFlowController) GetJPBalls() []DrawResult {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	return sortedByOrder(dfc.jpBalls)
+}
+
+// SetJPTriggerNumbers 設置JP觸發號碼（即幸運號碼），號碼需在球池範圍內、不重複且數量正確
+func (dfc *DataFlowController) SetJPTriggerNumbers(numbers []int) error {
 	dfc.mu.Lock()
 	defer dfc.mu.Unlock()
 
+	if err := dfc.validateLuckyNumbers(numbers); err != nil {
+		return err
+	}
+
 	dfc.jpTriggerNumbers = make([]int, len(numbers))
 	copy(dfc.jpTriggerNumbers, numbers)
+	dfc.notifyChangeLocked()
+	return nil
+}
+
+// GenerateLuckyNumbers 從球池中隨機產生幸運號碼並設置為JP觸發號碼
+func (dfc *DataFlowController) GenerateLuckyNumbers() ([]int, error) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	if dfc.luckyNumberCount > len(dfc.sourceBalls) {
+		return nil, newError(ErrInvalidArgument, "lucky number count %d exceeds ball pool size %d", dfc.luckyNumberCount, len(dfc.sourceBalls))
+	}
+
+	pool := make([]int, len(dfc.sourceBalls))
+	copy(pool, dfc.sourceBalls)
+	shuffleBalls(dfc.random, pool)
+
+	numbers := pool[:dfc.luckyNumberCount]
+	if err := dfc.validateLuckyNumbers(numbers); err != nil {
+		return nil, err
+	}
+
+	dfc.jpTriggerNumbers = make([]int, len(numbers))
+	copy(dfc.jpTriggerNumbers, numbers)
+	dfc.notifyChangeLocked()
+
+	result := make([]int, len(numbers))
+	copy(result, numbers)
+	return result, nil
+}
+
+// GetLuckyNumbers 獲取當前的幸運號碼
+func (dfc *DataFlowController) GetLuckyNumbers() []int {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	result := make([]int, len(dfc.jpTriggerNumbers))
+	copy(result, dfc.jpTriggerNumbers)
+	return result
 }
 
 // VerifyTwoBalls API 功能：驗證前端輸入的兩顆球
@@ -284,16 +782,25 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 	dfc.mu.RLock()
 	defer dfc.mu.RUnlock()
 
+	return dfc.buildGameStatusLocked()
+}
+
+// buildGameStatusLocked 組裝遊戲狀態快照，調用者必須持有讀鎖或寫鎖
+func (dfc *DataFlowController) buildGameStatusLocked() *GameStatusResponse {
 	// 創建時間數據
-	now := time.Now()
+	now := dfc.clock.Now()
 
-	// 使用實際時間，而不是模擬時間
+	// 使用實際記錄的狀態進入時間
+	gameStartTime := now
 	stateStartTime := now
 	if len(dfc.stateHistory) > 0 {
-		// 如果有狀態歷史，則可以假設最後狀態變更時間為5秒前（假設沒有實際記錄）
-		stateStartTime = now.Add(-5 * time.Second)
+		gameStartTime = dfc.stateHistory[0].EnteredAt
+		stateStartTime = dfc.stateHistory[len(dfc.stateHistory)-1].EnteredAt
 	}
 
+	stateHistory := make([]StateTransition, len(dfc.stateHistory))
+	copy(stateHistory, dfc.stateHistory)
+
 	// 只在遊戲完成時設置結束時間
 	var endTime *time.Time
 	if dfc.currentState == StateCompleted {
@@ -301,42 +808,23 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 		endTime = &t
 	}
 
-	// 將DrawResult轉換為BallInfo
-	drawnBalls := make([]BallInfo, 0, len(dfc.drawnBalls))
-	for _, ball := range dfc.drawnBalls {
-		drawnBalls = append(drawnBalls, BallInfo{
-			Number:    ball.BallNumber,
-			DrawnTime: ball.DrawTime,
-			Sequence:  ball.OrderIndex,
-		})
-	}
-
-	// 將額外球DrawResult轉換為ExtraBall
-	extraBalls := make([]ExtraBall, 0, len(dfc.extraBalls))
-	for i, ball := range dfc.extraBalls {
-		// 確定側邊位置 - 依據順序而非隨機
-		side := "LEFT"
-		if i%2 == 1 {
-			side = "RIGHT"
-		}
-
-		extraBalls = append(extraBalls, ExtraBall{
-			Number:    ball.BallNumber,
-			DrawnTime: ball.DrawTime,
-			Sequence:  ball.OrderIndex,
-			Side:      side,
-		})
-	}
+	drawnBalls := toBallInfos(sortedByOrder(dfc.drawnBalls))
+	extraBalls := toExtraBalls(sortedByOrder(dfc.extraBalls), dfc.selectedExtraBallSide)
+	jpBalls := toBallInfos(sortedByOrder(dfc.jpBalls))
 
 	// 創建空的幸運數字陣列，而不是隨機生成
 	luckyNumbers := make([]int, 0)
 	if dfc.currentState == StateShowLuckyNums {
-		// 當狀態是顯示幸運號碼時才會有值
-		// 後續可以修改為從資料庫或配置中獲取實際數值
-		luckyNumbers = make([]int, 7)
-		for i := 0; i < 7; i++ {
-			// 暫時使用固定值來替代模擬值
-			luckyNumbers[i] = (i + 1) * 10
+		// 當狀態是顯示幸運號碼時才會有值，已設置幸運號碼時使用實際數值
+		if len(dfc.jpTriggerNumbers) > 0 {
+			luckyNumbers = make([]int, len(dfc.jpTriggerNumbers))
+			copy(luckyNumbers, dfc.jpTriggerNumbers)
+		} else {
+			luckyNumbers = make([]int, dfc.luckyNumberCount)
+			for i := 0; i < dfc.luckyNumberCount; i++ {
+				// 暫時使用固定值來替代模擬值
+				luckyNumbers[i] = (i + 1) * 10
+			}
 		}
 	}
 
@@ -349,19 +837,23 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 	jackpotInfo := JackpotInfo{
 		Active:     dfc.isJPTriggered,
 		GameID:     nil,
-		Amount:     0, // 實際金額應從資料庫獲取
+		Amount:     dfc.jackpotAmount,
 		StartTime:  nil,
 		EndTime:    nil,
-		DrawnBalls: []BallInfo{},
+		DrawnBalls: jpBalls,
 		Winner:     nil,
 	}
 
+	if dfc.jackpotWinner != "" {
+		winner := dfc.jackpotWinner
+		jackpotInfo.Winner = &winner
+	}
+
 	// 只有當JP被觸發時才填充資料
 	if dfc.isJPTriggered {
 		jackpotInfo.GameID = &jpGameID
 		jpStart := now.Add(-time.Second)
 		jackpotInfo.StartTime = &jpStart
-		jackpotInfo.Amount = 500000 // 實際金額應該從配置或資料庫獲取
 	}
 
 	// 計算實際剩餘時間 - 基於狀態的預設持續時間
@@ -369,6 +861,9 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 	switch dfc.currentState {
 	case StateBetting:
 		stateDuration = 120 // 投注時間更長
+		if dfc.bettingDuration > 0 {
+			stateDuration = int(dfc.bettingDuration.Seconds())
+		}
 	case StateDrawing, StateJPDrawing:
 		stateDuration = 90 // 抽球時間適中
 	case StateExtraBet:
@@ -387,10 +882,13 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 		Game: GameInfo{
 			ID:             dfc.currentGameID,
 			State:          string(dfc.currentState),
-			StartTime:      stateStartTime,
+			Status:         StatusForState(dfc.currentState),
+			StartTime:      gameStartTime,
 			EndTime:        endTime,
 			HasJackpot:     dfc.isJPTriggered,
 			ExtraBallCount: dfc.maxExtraBalls,
+			StateHistory:   stateHistory,
+			ExtraBallSide:  dfc.selectedExtraBallSide,
 			Timeline: TimelineInfo{
 				CurrentTime:    now,
 				StateStartTime: stateStartTime,
@@ -411,48 +909,93 @@ func (dfc *DataFlowController) GetGameStatus() *GameStatusResponse {
 
 // Private helper methods
 
-// isValidStateTransition 檢查狀態轉換是否合法
-func (dfc *DataFlowController) isValidStateTransition(from, to GameState) bool {
-	validTransitions := map[GameState][]GameState{
-		StateInitial:   {StateStandby, StateReady, StateAgent},
-		StateAgent:     {StateReady},
-		StateStandby:   {StateBetting},
-		StateReady:     {StateBetting},
-		StateBetting:   {StateDrawing},
-		StateDrawing:   {StateExtraBet, StateJPStandby},
-		StateExtraBet:  {StateExtraDraw},
-		StateExtraDraw: {StateResult},
-		StateResult:    {StateStandby, StateCompleted},
-		StateJPStandby: {StateJPBetting},
-		StateJPBetting: {StateJPDrawing},
-		StateJPDrawing: {StateJPResult},
-		StateJPResult:  {StateStandby, StateCompleted},
-	}
-
-	allowedTransitions, exists := validTransitions[from]
-	if !exists {
-		return false
+// sortedByOrder 返回依 OrderIndex 由小到大排序的副本，不修改原切片
+func sortedByOrder(balls []DrawResult) []DrawResult {
+	result := make([]DrawResult, len(balls))
+	copy(result, balls)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].OrderIndex < result[j].OrderIndex
+	})
+	return result
+}
+
+// toBallInfos 將DrawResult轉換為BallInfo，主遊戲球與JP球共用
+func toBallInfos(balls []DrawResult) []BallInfo {
+	result := make([]BallInfo, 0, len(balls))
+	for _, ball := range balls {
+		result = append(result, BallInfo{
+			Number:    ball.BallNumber,
+			DrawnTime: ball.DrawTime,
+			Sequence:  ball.OrderIndex,
+		})
 	}
+	return result
+}
 
-	for _, allowed := range allowedTransitions {
-		if allowed == to {
-			return true
+// toExtraBalls 將額外球DrawResult轉換為ExtraBall，荷官已選定側邊時所有額外球使用該側邊
+func toExtraBalls(balls []DrawResult, selectedSide ExtraBallSide) []ExtraBall {
+	result := make([]ExtraBall, 0, len(balls))
+	for i, ball := range balls {
+		// 未選定側邊時依據順序交替，而非隨機
+		side := string(selectedSide)
+		if side == "" {
+			side = string(ExtraBallSideLeft)
+			if i%2 == 1 {
+				side = string(ExtraBallSideRight)
+			}
 		}
+
+		result = append(result, ExtraBall{
+			Number:    ball.BallNumber,
+			DrawnTime: ball.DrawTime,
+			Sequence:  ball.OrderIndex,
+			Side:      side,
+		})
+	}
+	return result
+}
+
+// validateLuckyNumbers 驗證幸運號碼的數量、範圍與唯一性
+func (dfc *DataFlowController) validateLuckyNumbers(numbers []int) error {
+	if len(numbers) != dfc.luckyNumberCount {
+		return newError(ErrInvalidArgument, "expected %d lucky numbers, got %d", dfc.luckyNumberCount, len(numbers))
 	}
 
-	return false
+	seen := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		if number < 1 || number > dfc.totalBalls {
+			return newError(ErrInvalidArgument, "lucky number %d out of range 1-%d", number, dfc.totalBalls)
+		}
+		if seen[number] {
+			return newError(ErrInvalidArgument, "duplicate lucky number %d", number)
+		}
+		seen[number] = true
+	}
+
+	return nil
 }
 
 // resetGame 重置遊戲狀態
 func (dfc *DataFlowController) resetGame() {
 	dfc.drawnBalls = make([]DrawResult, 0)
 	dfc.extraBalls = make([]DrawResult, 0)
+	dfc.jpBalls = make([]DrawResult, 0)
 	dfc.isJPTriggered = false
-	dfc.currentGameID = fmt.Sprintf("G%d", time.Now().UnixNano())
+	dfc.jpOverride = nil
+	dfc.selectedExtraBallSide = ""
+	dfc.jackpotWinner = ""
+	dfc.currentGameID = fmt.Sprintf("G%d", dfc.clock.Now().UnixNano())
+
+	// 新的一局從當前（待機）狀態開始記錄
+	dfc.stateHistory = dfc.stateHistory[len(dfc.stateHistory)-1:]
 }
 
-// checkJPTrigger 檢查是否觸發JP
+// checkJPTrigger 檢查是否觸發JP，荷官已手動設定時以手動設定為準
 func (dfc *DataFlowController) checkJPTrigger(ballNumber int) {
+	if dfc.jpOverride != nil {
+		dfc.isJPTriggered = *dfc.jpOverride
+		return
+	}
 	if len(dfc.jpTriggerNumbers) == 0 {
 		return
 	}
@@ -473,12 +1016,28 @@ func (dfc *DataFlowController) checkJPTrigger(ballNumber int) {
 	}
 }
 
+// recheckJPTrigger 依目前已抽出的球重新計算JP觸發狀態，荷官已手動設定時以手動設定為準
+func (dfc *DataFlowController) recheckJPTrigger() {
+	if dfc.jpOverride != nil {
+		dfc.isJPTriggered = *dfc.jpOverride
+		return
+	}
+
+	dfc.isJPTriggered = false
+	if len(dfc.drawnBalls) == 0 {
+		return
+	}
+
+	dfc.checkJPTrigger(dfc.drawnBalls[len(dfc.drawnBalls)-1].BallNumber)
+}
+
 // SetCurrentGameID 設置當前遊戲ID
 func (dfc *DataFlowController) SetCurrentGameID(gameID string) {
 	dfc.mu.Lock()
 	defer dfc.mu.Unlock()
 
 	dfc.currentGameID = gameID
+	dfc.notifyChangeLocked()
 }
 
 // GetCurrentGameID 獲取當前遊戲ID
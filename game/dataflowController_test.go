@@ -0,0 +1,1022 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 測試用的時間來源，只在 Advance 時前進
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 6, 19, 8, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestController 以固定種子與假時鐘創建控制器
+func newTestController(t *testing.T, cfg ControllerConfig) (*DataFlowController, *fakeClock) {
+	t.Helper()
+
+	clock := newFakeClock()
+	cfg.Clock = clock
+	if cfg.RandomSource == nil {
+		cfg.RandomSource = NewSeededBallSource(1)
+	}
+
+	dfc, err := NewDataFlowController(&cfg)
+	if err != nil {
+		t.Fatalf("NewDataFlowController: %v", err)
+	}
+	return dfc, clock
+}
+
+// moveTo 依序轉換到指定狀態
+func moveTo(t *testing.T, dfc *DataFlowController, states ...GameState) {
+	t.Helper()
+
+	for _, state := range states {
+		if err := dfc.ChangeState(state); err != nil {
+			t.Fatalf("ChangeState(%s): %v", state, err)
+		}
+	}
+}
+
+func TestExtraBallsUseSelectedSide(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing, StateExtraBet)
+
+	if err := dfc.SelectExtraBallSide(ExtraBallSideRight); err != nil {
+		t.Fatalf("SelectExtraBallSide: %v", err)
+	}
+	moveTo(t, dfc, StateExtraDraw)
+
+	for i := 0; i < 2; i++ {
+		if _, err := dfc.DrawExtraBall(); err != nil {
+			t.Fatalf("DrawExtraBall: %v", err)
+		}
+	}
+
+	status := dfc.GetGameStatus()
+	if status.Game.ExtraBallSide != ExtraBallSideRight {
+		t.Fatalf("game extra ball side = %q, want %q", status.Game.ExtraBallSide, ExtraBallSideRight)
+	}
+	for _, ball := range status.ExtraBalls {
+		if ball.Side != string(ExtraBallSideRight) {
+			t.Errorf("extra ball %d side = %q, want %q", ball.Sequence, ball.Side, ExtraBallSideRight)
+		}
+	}
+}
+
+func TestExtraBallsAlternateWithoutSelectedSide(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw)
+
+	for i := 0; i < 2; i++ {
+		if _, err := dfc.DrawExtraBall(); err != nil {
+			t.Fatalf("DrawExtraBall: %v", err)
+		}
+	}
+
+	balls := dfc.GetGameStatus().ExtraBalls
+	if balls[0].Side != string(ExtraBallSideLeft) || balls[1].Side != string(ExtraBallSideRight) {
+		t.Fatalf("extra ball sides = %q, %q, want LEFT, RIGHT", balls[0].Side, balls[1].Side)
+	}
+}
+
+func TestSelectExtraBallSideOnlyInExtraBet(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+
+	for _, state := range allStates {
+		dfc.currentState = state
+		err := dfc.SelectExtraBallSide(ExtraBallSideLeft)
+		if state == StateExtraBet {
+			if err != nil {
+				t.Errorf("SelectExtraBallSide in %s: %v", state, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidState) {
+			t.Errorf("SelectExtraBallSide in %s = %v, want ErrInvalidState", state, err)
+		}
+	}
+}
+
+// firstBallSource 總是選出候選中的第一顆球，讓抽球結果可預期
+type firstBallSource struct{}
+
+func (firstBallSource) Intn(int) int { return 0 }
+
+func TestManualJackpotFlagSurvivesDraw(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{LuckyNumberCount: 1, RandomSource: firstBallSource{}})
+	if err := dfc.SetJPTriggerNumbers([]int{1}); err != nil {
+		t.Fatalf("SetJPTriggerNumbers: %v", err)
+	}
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	if err := dfc.SetHasJackpot(false); err != nil {
+		t.Fatalf("SetHasJackpot: %v", err)
+	}
+
+	// 抽出觸發號碼 1，手動設定仍應保持不觸發
+	if _, err := dfc.DrawBall(); err != nil {
+		t.Fatalf("DrawBall: %v", err)
+	}
+	if dfc.GetGameStatus().Game.HasJackpot {
+		t.Fatal("HasJackpot = true after draw, want manual false to be kept")
+	}
+}
+
+func TestManualJackpotFlagSurvivesUndo(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{LuckyNumberCount: 1, RandomSource: firstBallSource{}})
+	if err := dfc.SetJPTriggerNumbers([]int{75}); err != nil {
+		t.Fatalf("SetJPTriggerNumbers: %v", err)
+	}
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	if err := dfc.SetHasJackpot(true); err != nil {
+		t.Fatalf("SetHasJackpot: %v", err)
+	}
+	if _, err := dfc.DrawBall(); err != nil {
+		t.Fatalf("DrawBall: %v", err)
+	}
+	if !dfc.GetGameStatus().Game.HasJackpot {
+		t.Fatal("HasJackpot = false after draw, want manual true to be kept")
+	}
+
+	if _, err := dfc.UndoLastBall(BallTypeRegular); err != nil {
+		t.Fatalf("UndoLastBall: %v", err)
+	}
+	if !dfc.GetGameStatus().Game.HasJackpot {
+		t.Fatal("HasJackpot = false after undo, want manual true to be kept")
+	}
+}
+
+func TestManualJackpotFlagClearedForNextRound(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting)
+
+	if err := dfc.SetHasJackpot(true); err != nil {
+		t.Fatalf("SetHasJackpot: %v", err)
+	}
+	moveTo(t, dfc, StateDrawing, StateExtraBet, StateExtraDraw, StateResult, StateStandby)
+
+	if dfc.GetGameStatus().Game.HasJackpot {
+		t.Fatal("HasJackpot = true in new round, want manual flag cleared")
+	}
+}
+
+// allStates 所有已定義的遊戲狀態
+var allStates = []GameState{
+	StateInitial, StateAgent, StateStandby, StateReady, StateShowLuckyNums,
+	StateBetting, StateDrawing, StateShowBalls, StateExtraBet, StateExtraDraw,
+	StateChooseExtraBall, StateShowExtraBalls, StateResult,
+	StateJPStandby, StateJPBetting, StateJPDrawing, StateJPResult, StateJPShowBalls,
+	StateCompleted,
+}
+
+// expectedTransitions 各狀態允許的下一個狀態，未列出的狀態不允許任何轉換
+var expectedTransitions = map[GameState][]GameState{
+	StateInitial:   {StateStandby, StateReady, StateAgent},
+	StateAgent:     {StateReady},
+	StateStandby:   {StateBetting},
+	StateReady:     {StateBetting},
+	StateBetting:   {StateDrawing},
+	StateDrawing:   {StateExtraBet, StateJPStandby},
+	StateExtraBet:  {StateExtraDraw},
+	StateExtraDraw: {StateResult},
+	StateResult:    {StateStandby, StateCompleted},
+	StateJPStandby: {StateJPBetting},
+	StateJPBetting: {StateJPDrawing},
+	StateJPDrawing: {StateJPResult},
+	StateJPResult:  {StateStandby, StateCompleted},
+}
+
+func containsState(states []GameState, target GameState) bool {
+	for _, state := range states {
+		if state == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCanTransitionFromEveryState(t *testing.T) {
+	for _, from := range allStates {
+		for _, to := range allStates {
+			want := containsState(expectedTransitions[from], to)
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestChangeStateFromEveryState(t *testing.T) {
+	for _, from := range allStates {
+		if from.IsTerminal() {
+			continue
+		}
+
+		t.Run(string(from), func(t *testing.T) {
+			allowed := expectedTransitions[from]
+
+			for _, to := range allStates {
+				dfc, _ := newTestController(t, ControllerConfig{})
+				dfc.currentState = from
+
+				err := dfc.ChangeState(to)
+				if containsState(allowed, to) {
+					if err != nil {
+						t.Errorf("ChangeState(%s -> %s) = %v, want success", from, to, err)
+					}
+					continue
+				}
+
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Errorf("ChangeState(%s -> %s) = %v, want ErrInvalidTransition", from, to, err)
+					continue
+				}
+				// 錯誤訊息列出所有允許的下一個狀態
+				if !strings.Contains(err.Error(), fmt.Sprintf("allowed next states: %v", allowed)) {
+					t.Errorf("ChangeState(%s -> %s) error = %q, want allowed next states %v", from, to, err, allowed)
+				}
+				if state := dfc.GetCurrentState(); state != from {
+					t.Errorf("state after rejected %s -> %s = %s, want unchanged", from, to, state)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeStateRejectedFromTerminalState(t *testing.T) {
+	paths := map[string][]GameState{
+		"after result": {
+			StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw, StateResult, StateCompleted,
+		},
+		"after JP result": {
+			StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing, StateJPResult, StateCompleted,
+		},
+	}
+
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			dfc, _ := newTestController(t, ControllerConfig{})
+			moveTo(t, dfc, path...)
+			history := len(dfc.GetStateHistory())
+
+			for _, to := range allStates {
+				err := dfc.ChangeState(to)
+				if !errors.Is(err, ErrInvalidTransition) || !strings.Contains(err.Error(), "terminal state") {
+					t.Errorf("ChangeState(%s -> %s) = %v, want a terminal state ErrInvalidTransition", StateCompleted, to, err)
+				}
+			}
+			if state := dfc.GetCurrentState(); state != StateCompleted {
+				t.Fatalf("state = %s after rejected transitions, want %s", state, StateCompleted)
+			}
+			if got := len(dfc.GetStateHistory()); got != history {
+				t.Fatalf("state history length = %d after rejected transitions, want %d", got, history)
+			}
+		})
+	}
+}
+
+// resultPath 與 jpResultPath 從初始狀態經完整一局到達結算的狀態序列
+var (
+	resultPath   = []GameState{StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw, StateResult}
+	jpResultPath = []GameState{StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing, StateJPResult}
+)
+
+func TestAutoResetAfterResult(t *testing.T) {
+	for name, path := range map[string][]GameState{"result": resultPath, "JP result": jpResultPath} {
+		t.Run(name, func(t *testing.T) {
+			dfc, _ := newTestController(t, ControllerConfig{AutoResetDelay: 20 * time.Millisecond})
+			moveTo(t, dfc, path[:3]...)
+			if _, err := dfc.DrawBall(); err != nil {
+				t.Fatalf("DrawBall: %v", err)
+			}
+			moveTo(t, dfc, path[3:]...)
+
+			waitForState(t, dfc, StateStandby)
+			if drawn := len(dfc.GetDrawnBalls()); drawn != 0 {
+				t.Fatalf("drawn balls after auto reset = %d, want 0", drawn)
+			}
+		})
+	}
+}
+
+func TestAutoResetDisabledByDefault(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, resultPath...)
+
+	time.Sleep(50 * time.Millisecond)
+	if state := dfc.GetCurrentState(); state != StateResult {
+		t.Fatalf("state = %s without an auto reset delay, want %s", state, StateResult)
+	}
+}
+
+func TestAutoResetCancelledByManualTransition(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	dfc, _ := newTestController(t, ControllerConfig{AutoResetDelay: delay})
+	moveTo(t, dfc, resultPath...)
+
+	// 等待途中手動開始下一局並再次結算，第一局的計時器被取消，重新計時
+	time.Sleep(delay / 2)
+	moveTo(t, dfc, StateStandby)
+	dfc.mu.RLock()
+	pending := dfc.autoResetTimer
+	dfc.mu.RUnlock()
+	if pending != nil {
+		t.Fatal("auto reset still pending after the manual transition")
+	}
+	moveTo(t, dfc, resultPath[1:]...)
+
+	// 超過第一局的重置時間但未到第二局的重置時間
+	time.Sleep(delay * 3 / 4)
+	if state := dfc.GetCurrentState(); state != StateResult {
+		t.Fatalf("state = %s before the rescheduled reset, want %s", state, StateResult)
+	}
+	waitForState(t, dfc, StateStandby)
+}
+
+func TestStatusForState(t *testing.T) {
+	want := map[GameState]GameStatus{
+		StateInitial:         GameStatusCreated,
+		StateAgent:           GameStatusCreated,
+		StateStandby:         GameStatusCreated,
+		StateReady:           GameStatusCreated,
+		StateShowLuckyNums:   GameStatusInProgress,
+		StateBetting:         GameStatusInProgress,
+		StateDrawing:         GameStatusInProgress,
+		StateShowBalls:       GameStatusInProgress,
+		StateExtraBet:        GameStatusInProgress,
+		StateExtraDraw:       GameStatusInProgress,
+		StateChooseExtraBall: GameStatusInProgress,
+		StateShowExtraBalls:  GameStatusInProgress,
+		StateJPStandby:       GameStatusInProgress,
+		StateJPBetting:       GameStatusInProgress,
+		StateJPDrawing:       GameStatusInProgress,
+		StateJPShowBalls:     GameStatusInProgress,
+		StateResult:          GameStatusCompleted,
+		StateJPResult:        GameStatusCompleted,
+		StateCompleted:       GameStatusCompleted,
+	}
+
+	for _, state := range allStates {
+		if got := StatusForState(state); got != want[state] {
+			t.Errorf("StatusForState(%s) = %s, want %s", state, got, want[state])
+		}
+	}
+}
+
+// unorderedBalls 返回 OrderIndex 未排序的抽球結果，球號為 OrderIndex 的十倍
+func unorderedBalls() []DrawResult {
+	return []DrawResult{
+		{BallNumber: 30, OrderIndex: 3},
+		{BallNumber: 10, OrderIndex: 1},
+		{BallNumber: 40, OrderIndex: 4},
+		{BallNumber: 20, OrderIndex: 2},
+	}
+}
+
+func TestSortedByOrder(t *testing.T) {
+	balls := unorderedBalls()
+
+	sorted := sortedByOrder(balls)
+	for i, ball := range sorted {
+		if ball.OrderIndex != i+1 || ball.BallNumber != (i+1)*10 {
+			t.Fatalf("sortedByOrder = %+v, want balls ordered by OrderIndex", sorted)
+		}
+	}
+
+	// 返回副本，不改變原切片的順序
+	if balls[0].OrderIndex != 3 {
+		t.Fatalf("input after sortedByOrder = %+v, want it unchanged", balls)
+	}
+}
+
+func TestGameStatusBallsOrderedByOrderIndex(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	dfc.drawnBalls = unorderedBalls()
+	dfc.extraBalls = unorderedBalls()
+	dfc.jpBalls = unorderedBalls()
+
+	status := dfc.GetGameStatus()
+	sequences := map[string][]int{}
+	for _, ball := range status.DrawnBalls {
+		sequences["drawn"] = append(sequences["drawn"], ball.Sequence)
+	}
+	for _, ball := range status.ExtraBalls {
+		sequences["extra"] = append(sequences["extra"], ball.Sequence)
+	}
+	for _, ball := range status.Jackpot.DrawnBalls {
+		sequences["jackpot"] = append(sequences["jackpot"], ball.Sequence)
+	}
+
+	for name, got := range sequences {
+		if fmt.Sprint(got) != "[1 2 3 4]" {
+			t.Errorf("%s ball sequences = %v, want [1 2 3 4]", name, got)
+		}
+	}
+	if len(sequences) != 3 {
+		t.Fatalf("status ball lists = %v, want drawn, extra and jackpot balls", sequences)
+	}
+
+	for name, balls := range map[string][]DrawResult{
+		"GetDrawnBalls": dfc.GetDrawnBalls(),
+		"GetExtraBalls": dfc.GetExtraBalls(),
+		"GetJPBalls":    dfc.GetJPBalls(),
+	} {
+		for i, ball := range balls {
+			if ball.OrderIndex != i+1 {
+				t.Errorf("%s = %+v, want balls ordered by OrderIndex", name, balls)
+				break
+			}
+		}
+	}
+}
+
+func TestStateHistoryRecordsTransitions(t *testing.T) {
+	dfc, clock := newTestController(t, ControllerConfig{})
+	start := clock.Now()
+
+	// 每次轉換前推進一秒，記錄的進入時間來自控制器時鐘
+	for _, state := range resultPath {
+		clock.Advance(time.Second)
+		moveTo(t, dfc, state)
+	}
+	if err := dfc.ChangeState(StateDrawing); err == nil {
+		t.Fatal("ChangeState(RESULT -> DRAWING) succeeded, want rejection")
+	}
+
+	history := dfc.GetStateHistory()
+	want := append([]GameState{StateAgent}, resultPath...)
+	if len(history) != len(want) {
+		t.Fatalf("state history = %+v, want %v without the rejected transition", history, want)
+	}
+	for i, entry := range history {
+		if entry.State != want[i] || !entry.EnteredAt.Equal(start.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("history[%d] = %+v, want %s entered at +%ds", i, entry, want[i], i)
+		}
+	}
+
+	// 返回副本，修改不影響控制器的記錄
+	history[0].State = StateCompleted
+	if first := dfc.GetStateHistory()[0]; first.State != StateAgent {
+		t.Fatalf("history[0] = %s after modifying the returned copy, want %s", first.State, StateAgent)
+	}
+}
+
+func TestStateHistoryClearedOnReset(t *testing.T) {
+	dfc, clock := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, resultPath...)
+
+	// 開始下一局時只保留進入待機的記錄
+	clock.Advance(time.Minute)
+	moveTo(t, dfc, StateStandby)
+	history := dfc.GetStateHistory()
+	if len(history) != 1 || history[0].State != StateStandby || !history[0].EnteredAt.Equal(clock.Now()) {
+		t.Fatalf("state history after reset = %+v, want only %s at the reset time", history, StateStandby)
+	}
+
+	moveTo(t, dfc, StateBetting)
+	if history := dfc.GetStateHistory(); len(history) != 2 || history[1].State != StateBetting {
+		t.Fatalf("state history in the next round = %+v, want %s then %s", history, StateStandby, StateBetting)
+	}
+}
+
+// playJPRound 從待機狀態開始一局，觸發JP並進入JP結算
+func playJPRound(t *testing.T, dfc *DataFlowController) {
+	t.Helper()
+
+	moveTo(t, dfc, StateBetting, StateDrawing)
+	if err := dfc.SetHasJackpot(true); err != nil {
+		t.Fatalf("SetHasJackpot: %v", err)
+	}
+	moveTo(t, dfc, StateJPStandby, StateJPBetting, StateJPDrawing, StateJPResult)
+}
+
+func TestJackpotFixedAmount(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{JackpotAmount: 1000})
+
+	// 未設定累積金額時，一般結算與JP開出後都維持基礎金額
+	moveTo(t, dfc, resultPath...)
+	moveTo(t, dfc, StateStandby)
+	playJPRound(t, dfc)
+	if err := dfc.SetJackpotWinner("U1"); err != nil {
+		t.Fatalf("SetJackpotWinner: %v", err)
+	}
+	moveTo(t, dfc, StateStandby)
+
+	if amount := dfc.GetJackpotAmount(); amount != 1000 {
+		t.Fatalf("jackpot = %v, want the fixed 1000", amount)
+	}
+}
+
+func TestJackpotProgressiveAmount(t *testing.T) {
+	newProgressive := func(t *testing.T) *DataFlowController {
+		dfc, _ := newTestController(t, ControllerConfig{JackpotAmount: 1000, JackpotIncrement: 100})
+
+		// 兩局未開出JP，每局結算累積一次
+		moveTo(t, dfc, resultPath...)
+		moveTo(t, dfc, StateStandby)
+		moveTo(t, dfc, resultPath[1:]...)
+		if amount := dfc.GetJackpotAmount(); amount != 1200 {
+			t.Fatalf("jackpot after two rounds = %v, want 1200", amount)
+		}
+		moveTo(t, dfc, StateStandby)
+		playJPRound(t, dfc)
+		return dfc
+	}
+
+	t.Run("reset when the winner is recorded", func(t *testing.T) {
+		dfc := newProgressive(t)
+		if err := dfc.SetJackpotWinner("U1"); err != nil {
+			t.Fatalf("SetJackpotWinner: %v", err)
+		}
+		if amount := dfc.GetJackpotAmount(); amount != 1000 {
+			t.Fatalf("jackpot after the winner is recorded = %v, want the base 1000", amount)
+		}
+
+		// 結束遊戲後不會帶入已派發的獎金
+		moveTo(t, dfc, StateCompleted)
+		if amount := dfc.GetJackpotAmount(); amount != 1000 {
+			t.Fatalf("jackpot after completing = %v, want the base 1000", amount)
+		}
+	})
+
+	for _, next := range []GameState{StateStandby, StateCompleted} {
+		t.Run("reset leaving JP result to "+string(next), func(t *testing.T) {
+			dfc := newProgressive(t)
+			if amount := dfc.GetJackpotAmount(); amount != 1200 {
+				t.Fatalf("jackpot in JP result = %v, want the accumulated 1200", amount)
+			}
+			moveTo(t, dfc, next)
+			if amount := dfc.GetJackpotAmount(); amount != 1000 {
+				t.Fatalf("jackpot after JP result -> %s = %v, want the base 1000", next, amount)
+			}
+		})
+	}
+}
+
+func TestSetJackpotWinnerOnlyInJPResult(t *testing.T) {
+	for _, state := range allStates {
+		dfc, _ := newTestController(t, ControllerConfig{})
+		dfc.currentState = state
+		dfc.isJPTriggered = true
+
+		err := dfc.SetJackpotWinner("U1")
+		if state == StateJPResult {
+			if err != nil {
+				t.Errorf("SetJackpotWinner in %s = %v, want success", state, err)
+			} else if winner := dfc.GetGameStatus().Jackpot.Winner; winner == nil || *winner != "U1" {
+				t.Errorf("jackpot winner = %v, want U1", winner)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidState) {
+			t.Errorf("SetJackpotWinner in %s = %v, want ErrInvalidState", state, err)
+		}
+		if winner := dfc.GetGameStatus().Jackpot.Winner; winner != nil {
+			t.Errorf("jackpot winner = %s after rejection in %s, want none", *winner, state)
+		}
+	}
+}
+
+func TestSetJackpotWinnerRejectsInvalidRequests(t *testing.T) {
+	// 未觸發JP的一局
+	dfc, _ := newTestController(t, ControllerConfig{})
+	dfc.currentState = StateJPResult
+	if err := dfc.SetJackpotWinner("U1"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("SetJackpotWinner without a jackpot = %v, want ErrInvalidState", err)
+	}
+
+	// 缺少獲勝者ID
+	dfc.isJPTriggered = true
+	if err := dfc.SetJackpotWinner(""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("SetJackpotWinner(\"\") = %v, want ErrInvalidArgument", err)
+	}
+}
+
+// convertedBalls 返回轉換測試使用的抽球結果
+func convertedBalls() []DrawResult {
+	drawn := time.Date(2024, 6, 19, 8, 6, 0, 0, time.UTC)
+	return []DrawResult{
+		{BallNumber: 5, DrawTime: drawn, OrderIndex: 1},
+		{BallNumber: 17, DrawTime: drawn.Add(time.Second), OrderIndex: 2},
+		{BallNumber: 42, DrawTime: drawn.Add(2 * time.Second), OrderIndex: 3},
+	}
+}
+
+func TestToBallInfos(t *testing.T) {
+	balls := convertedBalls()
+
+	infos := toBallInfos(balls)
+	if len(infos) != len(balls) {
+		t.Fatalf("toBallInfos returned %d balls, want %d", len(infos), len(balls))
+	}
+	for i, info := range infos {
+		want := BallInfo{Number: balls[i].BallNumber, DrawnTime: balls[i].DrawTime, Sequence: balls[i].OrderIndex}
+		if info != want {
+			t.Errorf("toBallInfos[%d] = %+v, want %+v", i, info, want)
+		}
+	}
+}
+
+func TestToExtraBalls(t *testing.T) {
+	balls := convertedBalls()
+
+	cases := []struct {
+		name  string
+		side  ExtraBallSide
+		sides []string
+	}{
+		{name: "selected left", side: ExtraBallSideLeft, sides: []string{"LEFT", "LEFT", "LEFT"}},
+		{name: "selected right", side: ExtraBallSideRight, sides: []string{"RIGHT", "RIGHT", "RIGHT"}},
+		{name: "alternate without selection", sides: []string{"LEFT", "RIGHT", "LEFT"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			extras := toExtraBalls(balls, tc.side)
+			if len(extras) != len(balls) {
+				t.Fatalf("toExtraBalls returned %d balls, want %d", len(extras), len(balls))
+			}
+			for i, extra := range extras {
+				want := ExtraBall{Number: balls[i].BallNumber, DrawnTime: balls[i].DrawTime, Sequence: balls[i].OrderIndex, Side: tc.sides[i]}
+				if extra != want {
+					t.Errorf("toExtraBalls[%d] = %+v, want %+v", i, extra, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBallConvertersEncodeEmptyListsAsArrays(t *testing.T) {
+	// 沒有球時輸出空陣列而非 null，客戶端可直接迭代
+	for name, value := range map[string]interface{}{
+		"toBallInfos":  toBallInfos(nil),
+		"toExtraBalls": toExtraBalls(nil, ""),
+	} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("encode %s: %v", name, err)
+		}
+		if string(data) != "[]" {
+			t.Errorf("%s(nil) encodes as %s, want []", name, data)
+		}
+	}
+}
+
+func TestNewDataFlowControllerRejectsBallCountsExceedingPool(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  ControllerConfig
+	}{
+		{name: "main draw exceeds pool", cfg: ControllerConfig{TotalBalls: 20, MainDrawCount: 21, JPDrawCount: 20, LuckyNumberCount: 1}},
+		{name: "extra balls overflow pool", cfg: ControllerConfig{TotalBalls: 20, MainDrawCount: 18, JPDrawCount: 20, MaxExtraBalls: 3, LuckyNumberCount: 1}},
+		{name: "JP draw exceeds pool", cfg: ControllerConfig{TotalBalls: 20, MainDrawCount: 10, JPDrawCount: 21, LuckyNumberCount: 1}},
+		{name: "lucky numbers exceed pool", cfg: ControllerConfig{TotalBalls: 20, MainDrawCount: 10, JPDrawCount: 20, LuckyNumberCount: 21}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewDataFlowController(&tc.cfg); !errors.Is(err, ErrInvalidArgument) {
+				t.Fatalf("NewDataFlowController(%+v) = %v, want ErrInvalidArgument", tc.cfg, err)
+			}
+		})
+	}
+}
+
+func TestDrawBallStopsAtMainDrawCount(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{MainDrawCount: 5})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	for i := 0; i < 5; i++ {
+		if _, err := dfc.DrawBall(); err != nil {
+			t.Fatalf("DrawBall %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := dfc.DrawBall(); !errors.Is(err, ErrBallsExhausted) {
+		t.Fatalf("DrawBall past main draw count = %v, want ErrBallsExhausted", err)
+	}
+	if count := len(dfc.GetDrawnBalls()); count != 5 {
+		t.Fatalf("drawn balls = %d, want 5", count)
+	}
+}
+
+func TestDrawJPBallStopsAtJPDrawCount(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{JPDrawCount: 5})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing)
+
+	for i := 0; i < 5; i++ {
+		if _, err := dfc.DrawJPBall(); err != nil {
+			t.Fatalf("DrawJPBall %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := dfc.DrawJPBall(); !errors.Is(err, ErrBallsExhausted) {
+		t.Fatalf("DrawJPBall past JP draw count = %v, want ErrBallsExhausted", err)
+	}
+	if count := len(dfc.GetGameStatus().Jackpot.DrawnBalls); count != 5 {
+		t.Fatalf("JP balls = %d, want 5", count)
+	}
+}
+
+func TestConfiguredBallPoolSize(t *testing.T) {
+	for _, total := range []int{15, 45} {
+		dfc, _ := newTestController(t, ControllerConfig{TotalBalls: total, MainDrawCount: total - 3, JPDrawCount: total, MaxExtraBalls: 3, LuckyNumberCount: 5})
+		moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+		seen := make(map[int]bool)
+		for i := 0; i < total-3; i++ {
+			result, err := dfc.DrawBall()
+			if err != nil {
+				t.Fatalf("%d balls: DrawBall %d: %v", total, i+1, err)
+			}
+			if result.BallNumber < 1 || result.BallNumber > total || seen[result.BallNumber] {
+				t.Fatalf("%d balls: drew %d, want a new ball in 1-%d", total, result.BallNumber, total)
+			}
+			seen[result.BallNumber] = true
+		}
+		if _, err := dfc.DrawBall(); !errors.Is(err, ErrBallsExhausted) {
+			t.Fatalf("%d balls: DrawBall past main draw count = %v, want ErrBallsExhausted", total, err)
+		}
+	}
+}
+
+func TestUndoLastRegularBall(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	drawn := make([]*DrawResult, 0, 3)
+	for i := 0; i < 3; i++ {
+		result, err := dfc.DrawBall()
+		if err != nil {
+			t.Fatalf("DrawBall: %v", err)
+		}
+		drawn = append(drawn, result)
+	}
+
+	removed, err := dfc.UndoLastBall(BallTypeRegular)
+	if err != nil {
+		t.Fatalf("UndoLastBall: %v", err)
+	}
+	if *removed != *drawn[2] {
+		t.Fatalf("removed = %+v, want the last drawn ball %+v", removed, drawn[2])
+	}
+
+	balls := dfc.GetDrawnBalls()
+	if len(balls) != 2 || balls[0] != *drawn[0] || balls[1] != *drawn[1] {
+		t.Fatalf("drawn balls after undo = %+v, want the first two draws", balls)
+	}
+
+	// 撤銷後再抽出的球接續順序
+	next, err := dfc.DrawBall()
+	if err != nil {
+		t.Fatalf("DrawBall after undo: %v", err)
+	}
+	if next.OrderIndex != 3 {
+		t.Fatalf("order index after undo = %d, want 3", next.OrderIndex)
+	}
+}
+
+func TestUndoLastExtraBall(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw)
+
+	first, err := dfc.DrawExtraBall()
+	if err != nil {
+		t.Fatalf("DrawExtraBall: %v", err)
+	}
+	second, err := dfc.DrawExtraBall()
+	if err != nil {
+		t.Fatalf("DrawExtraBall: %v", err)
+	}
+
+	removed, err := dfc.UndoLastBall(BallTypeExtra)
+	if err != nil {
+		t.Fatalf("UndoLastBall: %v", err)
+	}
+	if *removed != *second {
+		t.Fatalf("removed = %+v, want the last extra ball %+v", removed, second)
+	}
+	if balls := dfc.GetExtraBalls(); len(balls) != 1 || balls[0] != *first {
+		t.Fatalf("extra balls after undo = %+v, want only the first", balls)
+	}
+}
+
+func TestUndoLastBallRejectedOutsideDrawState(t *testing.T) {
+	cases := []struct {
+		ballType BallType
+		path     []GameState
+	}{
+		{BallTypeRegular, []GameState{StateReady, StateBetting}},
+		{BallTypeRegular, []GameState{StateReady, StateBetting, StateDrawing, StateExtraBet}},
+		{BallTypeRegular, []GameState{StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw}},
+		{BallTypeRegular, []GameState{StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing, StateJPResult}},
+		{BallTypeExtra, []GameState{StateReady, StateBetting, StateDrawing}},
+		{BallTypeExtra, []GameState{StateReady, StateBetting, StateDrawing, StateExtraBet}},
+		{BallTypeExtra, []GameState{StateReady, StateBetting, StateDrawing, StateExtraBet, StateExtraDraw, StateResult}},
+		{BallTypeExtra, []GameState{StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing}},
+	}
+
+	for _, tc := range cases {
+		state := tc.path[len(tc.path)-1]
+		t.Run(fmt.Sprintf("%s in %s", tc.ballType, state), func(t *testing.T) {
+			dfc, _ := newTestController(t, ControllerConfig{})
+			moveTo(t, dfc, tc.path...)
+
+			if _, err := dfc.UndoLastBall(tc.ballType); !errors.Is(err, ErrInvalidState) {
+				t.Fatalf("UndoLastBall(%s) in %s = %v, want ErrInvalidState", tc.ballType, state, err)
+			}
+		})
+	}
+}
+
+func TestUndoLastRegularBallInJPDrawing(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing, StateJPStandby, StateJPBetting, StateJPDrawing)
+
+	if _, err := dfc.DrawBall(); err != nil {
+		t.Fatalf("DrawBall: %v", err)
+	}
+	if _, err := dfc.UndoLastBall(BallTypeRegular); err != nil {
+		t.Fatalf("UndoLastBall in %s: %v", StateJPDrawing, err)
+	}
+	if count := len(dfc.GetDrawnBalls()); count != 0 {
+		t.Fatalf("drawn balls after undo = %d, want 0", count)
+	}
+}
+
+func TestUndoLastBallNothingToUndo(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	if _, err := dfc.UndoLastBall(BallTypeRegular); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("UndoLastBall with no balls = %v, want ErrNothingToUndo", err)
+	}
+	if _, err := dfc.UndoLastBall("BONUS"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("UndoLastBall(BONUS) = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestSetJPTriggerNumbersRejectsInvalidSets(t *testing.T) {
+	cases := []struct {
+		name    string
+		numbers []int
+		want    string
+	}{
+		{name: "duplicate", numbers: []int{1, 2, 3, 4, 5, 6, 6}, want: "duplicate lucky number 6"},
+		{name: "below range", numbers: []int{0, 2, 3, 4, 5, 6, 7}, want: "lucky number 0 out of range 1-75"},
+		{name: "above range", numbers: []int{1, 2, 3, 4, 5, 6, 76}, want: "lucky number 76 out of range 1-75"},
+		{name: "too few", numbers: []int{1, 2, 3}, want: "expected 7 lucky numbers, got 3"},
+		{name: "too many", numbers: []int{1, 2, 3, 4, 5, 6, 7, 8}, want: "expected 7 lucky numbers, got 8"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dfc, _ := newTestController(t, ControllerConfig{})
+
+			err := dfc.SetJPTriggerNumbers(tc.numbers)
+			if !errors.Is(err, ErrInvalidArgument) || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("SetJPTriggerNumbers(%v) = %v, want ErrInvalidArgument %q", tc.numbers, err, tc.want)
+			}
+			// 被拒絕的號碼不會覆蓋現有設定
+			if numbers := dfc.GetLuckyNumbers(); len(numbers) != 0 {
+				t.Fatalf("lucky numbers after rejected set = %v, want none", numbers)
+			}
+		})
+	}
+}
+
+func TestSetJPTriggerNumbersAcceptsValidSet(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+	numbers := []int{1, 15, 30, 45, 60, 74, 75}
+
+	if err := dfc.SetJPTriggerNumbers(numbers); err != nil {
+		t.Fatalf("SetJPTriggerNumbers(%v): %v", numbers, err)
+	}
+	if got := dfc.GetLuckyNumbers(); fmt.Sprint(got) != fmt.Sprint(numbers) {
+		t.Fatalf("lucky numbers = %v, want %v", got, numbers)
+	}
+}
+
+// assertValidLuckyNumbers 檢查幸運號碼的數量、範圍與唯一性
+func assertValidLuckyNumbers(t *testing.T, numbers []int, count, totalBalls int) {
+	t.Helper()
+
+	if len(numbers) != count {
+		t.Fatalf("lucky numbers = %v, want %d numbers", numbers, count)
+	}
+	seen := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		if number < 1 || number > totalBalls {
+			t.Fatalf("lucky numbers = %v, %d outside 1-%d", numbers, number, totalBalls)
+		}
+		if seen[number] {
+			t.Fatalf("lucky numbers = %v, %d repeated", numbers, number)
+		}
+		seen[number] = true
+	}
+}
+
+func TestGenerateLuckyNumbersMeetConstraints(t *testing.T) {
+	// 不同種子與較小的球池都應產生不重複且在範圍內的號碼
+	for seed := int64(1); seed <= 50; seed++ {
+		dfc, _ := newTestController(t, ControllerConfig{TotalBalls: 10, MainDrawCount: 5, JPDrawCount: 10, LuckyNumberCount: 7, RandomSource: NewSeededBallSource(seed)})
+
+		numbers, err := dfc.GenerateLuckyNumbers()
+		if err != nil {
+			t.Fatalf("seed %d: GenerateLuckyNumbers: %v", seed, err)
+		}
+		assertValidLuckyNumbers(t, numbers, 7, 10)
+
+		if stored := dfc.GetLuckyNumbers(); fmt.Sprint(stored) != fmt.Sprint(numbers) {
+			t.Fatalf("seed %d: stored lucky numbers = %v, want generated %v", seed, stored, numbers)
+		}
+	}
+}
+
+func TestConfiguredLuckyNumberCount(t *testing.T) {
+	for _, count := range []int{5, 10} {
+		t.Run(fmt.Sprintf("%d lucky numbers", count), func(t *testing.T) {
+			dfc, _ := newTestController(t, ControllerConfig{LuckyNumberCount: count})
+
+			numbers, err := dfc.GenerateLuckyNumbers()
+			if err != nil {
+				t.Fatalf("GenerateLuckyNumbers: %v", err)
+			}
+			assertValidLuckyNumbers(t, numbers, count, 75)
+
+			// 超過設定數量的號碼被拒絕，錯誤訊息帶有設定的數量
+			tooMany := make([]int, count+1)
+			for i := range tooMany {
+				tooMany[i] = i + 1
+			}
+			err = dfc.SetJPTriggerNumbers(tooMany)
+			if want := fmt.Sprintf("expected %d lucky numbers, got %d", count, count+1); err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("SetJPTriggerNumbers(%d numbers) = %v, want %q", count+1, err, want)
+			}
+		})
+	}
+}
+
+// lockProbeClock 每次取時間時檢查控制器的鎖是否允許讀取，用於確認操作期間一直持有寫鎖
+type lockProbeClock struct {
+	*fakeClock
+
+	dfc      *DataFlowController
+	unlocked int // 取時間時鎖可被讀取的次數
+	calls    int
+}
+
+func (c *lockProbeClock) Now() time.Time {
+	c.calls++
+	if c.dfc.mu.TryRLock() {
+		c.dfc.mu.RUnlock()
+		c.unlocked++
+	}
+	return c.fakeClock.Now()
+}
+
+func TestChangeStateAndGetStatusIsAtomic(t *testing.T) {
+	dfc, clock := newTestController(t, ControllerConfig{})
+	moveTo(t, dfc, StateReady, StateBetting)
+
+	probe := &lockProbeClock{fakeClock: clock, dfc: dfc}
+	dfc.clock = probe
+
+	status, err := dfc.ChangeStateAndGetStatus(StateDrawing)
+	if err != nil {
+		t.Fatalf("ChangeStateAndGetStatus: %v", err)
+	}
+
+	// 轉換記錄與狀態快照都在同一把寫鎖內取時間，期間其他操作無法插入
+	if probe.calls < 2 || probe.unlocked != 0 {
+		t.Fatalf("clock read %d times with %d outside the write lock, want the transition and status built under one lock", probe.calls, probe.unlocked)
+	}
+	history := status.Game.StateHistory
+	if status.Game.State != string(StateDrawing) || history[len(history)-1].State != StateDrawing {
+		t.Fatalf("status state = %s, history %+v, want %s", status.Game.State, history, StateDrawing)
+	}
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 遊戲流程錯誤分類，調用方應以 errors.Is 判斷，而非比對錯誤訊息
+var (
+	// 當前狀態不允許該操作（例如非抽球階段抽球）
+	ErrInvalidState = errors.New("operation not allowed in current state")
+	// 不合法的狀態轉換，或遊戲已進入終止狀態
+	ErrInvalidTransition = errors.New("invalid state transition")
+	// 球池已抽完或已達抽球上限
+	ErrBallsExhausted = errors.New("no more balls available")
+	// 沒有可撤銷的球
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// 參數不合法（球號、幸運號碼、球類型、側邊等）
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+// ErrorCode 返回錯誤分類的機器可讀代碼，未分類的錯誤返回空字串
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidState):
+		return "INVALID_STATE"
+	case errors.Is(err, ErrInvalidTransition):
+		return "INVALID_TRANSITION"
+	case errors.Is(err, ErrBallsExhausted):
+		return "BALLS_EXHAUSTED"
+	case errors.Is(err, ErrNothingToUndo):
+		return "NOTHING_TO_UNDO"
+	case errors.Is(err, ErrInvalidArgument):
+		return "INVALID_ARGUMENT"
+	}
+	return ""
+}
+
+// HTTPStatus 返回錯誤分類對應的 HTTP 狀態碼，與當前狀態衝突的錯誤返回 409，其餘返回 400
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidState),
+		errors.Is(err, ErrInvalidTransition),
+		errors.Is(err, ErrBallsExhausted),
+		errors.Is(err, ErrNothingToUndo):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// gameError 帶有分類的錯誤，Error() 保留原有的錯誤訊息
+type gameError struct {
+	kind error
+	msg  string
+}
+
+func (e *gameError) Error() string {
+	return e.msg
+}
+
+func (e *gameError) Unwrap() error {
+	return e.kind
+}
+
+// newError 創建指定分類的錯誤
+func newError(kind error, format string, args ...interface{}) error {
+	return &gameError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
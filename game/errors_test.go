@@ -0,0 +1,54 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid state", newError(ErrInvalidState, "cannot draw ball in state %s", StateBetting), http.StatusConflict},
+		{"invalid transition", newError(ErrInvalidTransition, "invalid state transition"), http.StatusConflict},
+		{"balls exhausted", newError(ErrBallsExhausted, "no more balls available"), http.StatusConflict},
+		{"nothing to undo", newError(ErrNothingToUndo, "no balls to undo"), http.StatusConflict},
+		{"invalid argument", newError(ErrInvalidArgument, "invalid ball type"), http.StatusBadRequest},
+		{"wrapped sentinel", fmt.Errorf("draw: %w", ErrBallsExhausted), http.StatusConflict},
+		{"unclassified", errors.New("unexpected"), http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := HTTPStatus(tc.err); got != tc.want {
+				t.Fatalf("HTTPStatus(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	cases := map[error]string{
+		ErrInvalidState:          "INVALID_STATE",
+		ErrInvalidTransition:     "INVALID_TRANSITION",
+		ErrBallsExhausted:        "BALLS_EXHAUSTED",
+		ErrNothingToUndo:         "NOTHING_TO_UNDO",
+		ErrInvalidArgument:       "INVALID_ARGUMENT",
+		errors.New("unexpected"): "",
+	}
+
+	for kind, want := range cases {
+		// 分類錯誤保留原訊息，ErrorCode 依分類判斷
+		err := newError(kind, "operation failed")
+		if got := ErrorCode(err); got != want {
+			t.Errorf("ErrorCode(%v error) = %q, want %q", kind, got, want)
+		}
+		if err.Error() != "operation failed" {
+			t.Errorf("error message = %q, want the formatted message", err.Error())
+		}
+	}
+}
@@ -45,6 +45,10 @@ type GameInfo struct {
 	// @example BETTING
 	State string `json:"state"`
 
+	// 遊戲整體進度，由當前狀態推導：CREATED、IN_PROGRESS 或 COMPLETED
+	// @example IN_PROGRESS
+	Status GameStatus `json:"status"`
+
 	// 遊戲開始時間
 	// @example 2024-06-19T08:00:00Z
 	StartTime time.Time `json:"startTime"`
@@ -61,6 +65,14 @@ type GameInfo struct {
 	// @example 3
 	ExtraBallCount int `json:"extraBallCount"`
 
+	// 荷官選定的額外球側邊（LEFT或RIGHT），未選定為空字串
+	// @example LEFT
+	ExtraBallSide ExtraBallSide `json:"extraBallSide"`
+
+	// 本局進入各狀態的記錄，最後一筆為當前狀態
+	// @example [{"state":"STANDBY","enteredAt":"2024-06-19T08:00:00Z"},{"state":"BETTING","enteredAt":"2024-06-19T08:05:00Z"}]
+	StateHistory []StateTransition `json:"stateHistory"`
+
 	// 時間相關信息
 	// @example {"currentTime":"2024-06-19T08:05:30Z","stateStartTime":"2024-06-19T08:05:00Z","remainingTime":25,"maxTimeout":60}
 	Timeline TimelineInfo `json:"timeline"`
@@ -133,7 +145,7 @@ type JackpotInfo struct {
 	// @example null
 	GameID *string `json:"gameId"`
 
-	// JP獎金金額
+	// 目前JP獎金金額，累積型JP會隨局數增加
 	// @example 500000
 	Amount float64 `json:"amount"`
 
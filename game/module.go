@@ -1,11 +1,42 @@
 package game
 
-import "go.uber.org/fx"
+import (
+	"log"
+
+	"g38_lottery_service/internal/config"
+
+	"go.uber.org/fx"
+)
 
 // Module 是遊戲模組
 var Module = fx.Module("game",
 	fx.Provide(
+		// 基於 Config 轉換為遊戲流程控制器設定
+		provideControllerConfig,
 		// 提供遊戲流程控制器
 		NewDataFlowController,
 	),
 )
+
+// provideControllerConfig 將應用配置轉換為遊戲流程控制器設定
+func provideControllerConfig(cfg *config.Config) *ControllerConfig {
+	controllerConfig := &ControllerConfig{
+		LuckyNumberCount: cfg.Game.LuckyNumberCount,
+		TotalBalls:       cfg.Game.TotalBalls,
+		MainDrawCount:    cfg.Game.MainDrawCount,
+		JPDrawCount:      cfg.Game.JPDrawCount,
+		MaxExtraBalls:    cfg.Game.MaxExtraBalls,
+		AutoResetDelay:   cfg.Game.AutoResetDelay,
+		BettingDuration:  cfg.Game.BettingDuration,
+		JackpotAmount:    cfg.Game.JackpotAmount,
+		JackpotIncrement: cfg.Game.JackpotIncrement,
+	}
+
+	// 指定種子時使用可重現的隨機源，相同種子產生相同的球序
+	if cfg.Game.BallRandomSeed != 0 {
+		log.Printf("警告: 使用固定抽球隨機種子 %d，僅應用於測試或稽核重現", cfg.Game.BallRandomSeed)
+		controllerConfig.RandomSource = NewSeededBallSource(cfg.Game.BallRandomSeed)
+	}
+
+	return controllerConfig
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"g38_lottery_service/internal/config"
+)
+
+func TestProvideControllerConfigMapsBallCounts(t *testing.T) {
+	for _, total := range []int{15, 45} {
+		cfg := &config.Config{}
+		cfg.Game.TotalBalls = total
+		cfg.Game.MainDrawCount = total - 5
+		cfg.Game.JPDrawCount = total
+		cfg.Game.MaxExtraBalls = 2
+		cfg.Game.LuckyNumberCount = 5
+
+		controllerConfig := provideControllerConfig(cfg)
+		if controllerConfig.TotalBalls != total || controllerConfig.MainDrawCount != total-5 ||
+			controllerConfig.JPDrawCount != total ||
+			controllerConfig.MaxExtraBalls != 2 || controllerConfig.LuckyNumberCount != 5 {
+			t.Fatalf("controller config = %+v, want ball counts from game config with %d balls", controllerConfig, total)
+		}
+	}
+}
+
+func TestProvideControllerConfigUsesSeededSource(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Game.BallRandomSeed = 42
+
+	controllerConfig := provideControllerConfig(cfg)
+	if _, ok := controllerConfig.RandomSource.(*seededBallSource); !ok {
+		t.Fatalf("random source = %T, want seeded source for BALL_RANDOM_SEED", controllerConfig.RandomSource)
+	}
+
+	// 相同種子的控制器抽出相同球序
+	first := drawSequence(t, provideControllerConfig(cfg).RandomSource)
+	second := drawSequence(t, provideControllerConfig(cfg).RandomSource)
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("draws with BALL_RANDOM_SEED=42 differ:\n%v\n%v", first, second)
+	}
+	if want := drawSequence(t, NewSeededBallSource(42)); fmt.Sprint(first) != fmt.Sprint(want) {
+		t.Fatalf("draws = %v, want the seed 42 sequence %v", first, want)
+	}
+}
+
+func TestProvideControllerConfigDefaultsToCryptoSource(t *testing.T) {
+	controllerConfig := provideControllerConfig(&config.Config{})
+	if controllerConfig.RandomSource != nil {
+		t.Fatalf("random source = %T without a seed, want nil so the controller uses crypto/rand", controllerConfig.RandomSource)
+	}
+
+	dfc, err := NewDataFlowController(controllerConfig)
+	if err != nil {
+		t.Fatalf("NewDataFlowController: %v", err)
+	}
+	if _, ok := dfc.random.(cryptoBallSource); !ok {
+		t.Fatalf("controller random source = %T, want crypto source", dfc.random)
+	}
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	mathrand "math/rand"
+	"sync"
+	"time"
+)
+
+// RandomBallSource 抽球使用的隨機數來源，所有選球與洗牌都經由此介面
+type RandomBallSource interface {
+	// Intn 回傳 [0,n) 範圍內的隨機整數
+	Intn(n int) int
+}
+
+// cryptoBallSource 使用 crypto/rand 的隨機數來源，作為正式環境的預設值
+type cryptoBallSource struct{}
+
+// NewCryptoBallSource 創建使用 crypto/rand 的隨機數來源
+func NewCryptoBallSource() RandomBallSource {
+	return cryptoBallSource{}
+}
+
+// Intn 回傳 [0,n) 範圍內的隨機整數
+func (cryptoBallSource) Intn(n int) int {
+	v, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// 系統熵來源不可用時退回使用時間種子的偽隨機數
+		return mathrand.New(mathrand.NewSource(time.Now().UnixNano())).Intn(n)
+	}
+	return int(v.Int64())
+}
+
+// seededBallSource 使用固定種子的隨機數來源，相同種子會產生相同的序列
+type seededBallSource struct {
+	mu  sync.Mutex
+	rng *mathrand.Rand
+}
+
+// NewSeededBallSource 創建使用固定種子的隨機數來源，用於可重現的測試與稽核
+func NewSeededBallSource(seed int64) RandomBallSource {
+	return &seededBallSource{
+		rng: mathrand.New(mathrand.NewSource(seed)),
+	}
+}
+
+// Intn 回傳 [0,n) 範圍內的隨機整數
+func (s *seededBallSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Intn(n)
+}
+
+// pickBall 從候選球中隨機選出一顆
+func pickBall(source RandomBallSource, candidates []int) int {
+	return candidates[source.Intn(len(candidates))]
+}
+
+// shuffleBalls 使用 Fisher-Yates 演算法就地打亂球的順序
+func shuffleBalls(source RandomBallSource, balls []int) {
+	for i := len(balls) - 1; i > 0; i-- {
+		j := source.Intn(i + 1)
+		balls[i], balls[j] = balls[j], balls[i]
+	}
+}
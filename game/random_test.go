@@ -0,0 +1,75 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// drawSequence 以指定隨機源抽完一局主遊戲球，返回抽出的球號
+func drawSequence(t *testing.T, source RandomBallSource) []int {
+	t.Helper()
+
+	dfc, _ := newTestController(t, ControllerConfig{RandomSource: source})
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+
+	numbers := make([]int, 0, 30)
+	for i := 0; i < 30; i++ {
+		result, err := dfc.DrawBall()
+		if err != nil {
+			t.Fatalf("DrawBall %d: %v", i+1, err)
+		}
+		numbers = append(numbers, result.BallNumber)
+	}
+	return numbers
+}
+
+func TestSeededSourceReproducesDrawSequence(t *testing.T) {
+	first := drawSequence(t, NewSeededBallSource(42))
+	second := drawSequence(t, NewSeededBallSource(42))
+
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("draws with seed 42 differ:\n%v\n%v", first, second)
+	}
+
+	// 不同種子應產生不同球序
+	if other := drawSequence(t, NewSeededBallSource(43)); fmt.Sprint(first) == fmt.Sprint(other) {
+		t.Fatalf("seeds 42 and 43 produced the same draws %v", first)
+	}
+}
+
+func TestSeededSourceReproducesLuckyNumbers(t *testing.T) {
+	generate := func() []int {
+		dfc, _ := newTestController(t, ControllerConfig{RandomSource: NewSeededBallSource(7)})
+		numbers, err := dfc.GenerateLuckyNumbers()
+		if err != nil {
+			t.Fatalf("GenerateLuckyNumbers: %v", err)
+		}
+		return numbers
+	}
+
+	if first, second := generate(), generate(); fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("lucky numbers with seed 7 differ: %v, %v", first, second)
+	}
+}
+
+func TestShuffleBallsKeepsEveryBall(t *testing.T) {
+	balls := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	shuffleBalls(NewSeededBallSource(1), balls)
+
+	seen := make(map[int]bool, len(balls))
+	for _, ball := range balls {
+		seen[ball] = true
+	}
+	if len(seen) != 10 {
+		t.Fatalf("shuffled balls = %v, want a permutation of 1-10", balls)
+	}
+}
+
+func TestCryptoSourceInRange(t *testing.T) {
+	source := NewCryptoBallSource()
+	for i := 0; i < 1000; i++ {
+		if n := source.Intn(5); n < 0 || n >= 5 {
+			t.Fatalf("Intn(5) = %d, want 0-4", n)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"time"
+)
+
+// SnapshotVersion 快照結構版本，結構不相容地調整時需遞增
+const SnapshotVersion = 1
+
+// Snapshot 遊戲流程控制器的可序列化快照，用於程序重啟後恢復進行中的遊戲
+type Snapshot struct {
+	Version               int               `json:"version"`
+	GameID                string            `json:"gameId"`
+	State                 GameState         `json:"state"`
+	StateHistory          []StateTransition `json:"stateHistory"`
+	DrawnBalls            []DrawResult      `json:"drawnBalls"`
+	ExtraBalls            []DrawResult      `json:"extraBalls"`
+	JPBalls               []DrawResult      `json:"jpBalls"`
+	JPTriggerNumbers      []int             `json:"jpTriggerNumbers"`
+	IsJPTriggered         bool              `json:"isJPTriggered"`
+	JPOverride            *bool             `json:"jpOverride,omitempty"`
+	SelectedExtraBallSide ExtraBallSide     `json:"selectedExtraBallSide,omitempty"`
+	JackpotAmount         float64           `json:"jackpotAmount,omitempty"`
+	JackpotWinner         string            `json:"jackpotWinner,omitempty"`
+	SavedAt               time.Time         `json:"savedAt"`
+}
+
+// SetChangeListener 設置狀態變更監聽器，每次成功變更後以最新快照調用；
+// 監聽器在持有寫鎖時調用，不可阻塞也不可回調控制器
+func (dfc *DataFlowController) SetChangeListener(listener func(*Snapshot)) {
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	dfc.changeListener = listener
+}
+
+// Snapshot 返回當前遊戲的快照
+func (dfc *DataFlowController) Snapshot() *Snapshot {
+	dfc.mu.RLock()
+	defer dfc.mu.RUnlock()
+
+	return dfc.snapshotLocked()
+}
+
+// snapshotLocked 建立當前遊戲的快照，調用者必須持有鎖
+func (dfc *DataFlowController) snapshotLocked() *Snapshot {
+	return &Snapshot{
+		Version:               SnapshotVersion,
+		GameID:                dfc.currentGameID,
+		State:                 dfc.currentState,
+		StateHistory:          append([]StateTransition(nil), dfc.stateHistory...),
+		DrawnBalls:            append([]DrawResult(nil), dfc.drawnBalls...),
+		ExtraBalls:            append([]DrawResult(nil), dfc.extraBalls...),
+		JPBalls:               append([]DrawResult(nil), dfc.jpBalls...),
+		JPTriggerNumbers:      append([]int(nil), dfc.jpTriggerNumbers...),
+		IsJPTriggered:         dfc.isJPTriggered,
+		JPOverride:            copyBoolPtr(dfc.jpOverride),
+		SelectedExtraBallSide: dfc.selectedExtraBallSide,
+		JackpotAmount:         dfc.jackpotAmount,
+		JackpotWinner:         dfc.jackpotWinner,
+		SavedAt:               dfc.clock.Now(),
+	}
+}
+
+// notifyChangeLocked 通知監聽器遊戲已變更，調用者必須持有寫鎖
+func (dfc *DataFlowController) notifyChangeLocked() {
+	if dfc.changeListener != nil {
+		dfc.changeListener(dfc.snapshotLocked())
+	}
+}
+
+// Restore 以快照恢復遊戲，版本不符或內容與目前球池設定不一致時返回錯誤
+func (dfc *DataFlowController) Restore(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return newError(ErrInvalidArgument, "snapshot is nil")
+	}
+	if snapshot.Version != SnapshotVersion {
+		return newError(ErrInvalidArgument, "unsupported snapshot version %d, expected %d", snapshot.Version, SnapshotVersion)
+	}
+	if !isReachableState(snapshot.State) {
+		return newError(ErrInvalidArgument, "unknown state in snapshot: %s", snapshot.State)
+	}
+
+	dfc.mu.Lock()
+	defer dfc.mu.Unlock()
+
+	for _, balls := range [][]DrawResult{snapshot.DrawnBalls, snapshot.ExtraBalls, snapshot.JPBalls} {
+		for _, ball := range balls {
+			if ball.BallNumber < 1 || ball.BallNumber > dfc.totalBalls {
+				return newError(ErrInvalidArgument, "ball %d in snapshot out of range 1-%d", ball.BallNumber, dfc.totalBalls)
+			}
+		}
+	}
+
+	dfc.stopAutoResetLocked()
+
+	dfc.currentGameID = snapshot.GameID
+	dfc.currentState = snapshot.State
+	dfc.stateHistory = append([]StateTransition(nil), snapshot.StateHistory...)
+	if len(dfc.stateHistory) == 0 {
+		dfc.stateHistory = []StateTransition{{State: snapshot.State, EnteredAt: snapshot.SavedAt}}
+	}
+	dfc.drawnBalls = append(make([]DrawResult, 0, len(snapshot.DrawnBalls)), snapshot.DrawnBalls...)
+	dfc.extraBalls = append(make([]DrawResult, 0, len(snapshot.ExtraBalls)), snapshot.ExtraBalls...)
+	dfc.jpBalls = append(make([]DrawResult, 0, len(snapshot.JPBalls)), snapshot.JPBalls...)
+	dfc.jpTriggerNumbers = append(make([]int, 0, len(snapshot.JPTriggerNumbers)), snapshot.JPTriggerNumbers...)
+	dfc.isJPTriggered = snapshot.IsJPTriggered
+	dfc.jpOverride = copyBoolPtr(snapshot.JPOverride)
+	dfc.selectedExtraBallSide = snapshot.SelectedExtraBallSide
+	dfc.jackpotWinner = snapshot.JackpotWinner
+
+	// 累積中的JP獎金跨重啟保留，未記錄時沿用基礎金額
+	if snapshot.JackpotAmount > 0 {
+		dfc.jackpotAmount = snapshot.JackpotAmount
+	}
+
+	// 重啟前已進入結算狀態的遊戲重新排程自動重置
+	if dfc.currentState == StateResult || dfc.currentState == StateJPResult {
+		dfc.scheduleAutoResetLocked()
+	}
+
+	// 重啟前處於投注階段的遊戲以剩餘時間繼續倒數
+	dfc.stopCountdownLocked()
+	if dfc.currentState == StateBetting && dfc.bettingDuration > 0 {
+		enteredAt := dfc.stateHistory[len(dfc.stateHistory)-1].EnteredAt
+		remaining := dfc.bettingDuration - dfc.clock.Now().Sub(enteredAt)
+		if remaining < 0 {
+			remaining = 0
+		}
+		dfc.startCountdownLocked(remaining, StateDrawing)
+	}
+
+	return nil
+}
+
+// copyBoolPtr 複製布林指標，避免快照與控制器共用同一個值
+func copyBoolPtr(v *bool) *bool {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
+
+// isReachableState 判斷狀態是否出現在狀態轉換表中
+func isReachableState(state GameState) bool {
+	for from, nextStates := range transitions {
+		if from == state {
+			return true
+		}
+		for _, next := range nextStates {
+			if next == state {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// roundTrip 將快照經 JSON 序列化後還原，模擬寫入 Redis 再讀回
+func roundTrip(t *testing.T, snapshot *Snapshot) *Snapshot {
+	t.Helper()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("marshal snapshot: %v", err)
+	}
+	var restored Snapshot
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("unmarshal snapshot: %v", err)
+	}
+	return &restored
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{LuckyNumberCount: 1})
+	if err := dfc.SetJPTriggerNumbers([]int{75}); err != nil {
+		t.Fatalf("SetJPTriggerNumbers: %v", err)
+	}
+	moveTo(t, dfc, StateReady, StateBetting, StateDrawing)
+	for i := 0; i < 5; i++ {
+		if _, err := dfc.DrawBall(); err != nil {
+			t.Fatalf("DrawBall: %v", err)
+		}
+	}
+	if err := dfc.SetHasJackpot(false); err != nil {
+		t.Fatalf("SetHasJackpot: %v", err)
+	}
+
+	snapshot := roundTrip(t, dfc.Snapshot())
+
+	restored, _ := newTestController(t, ControllerConfig{LuckyNumberCount: 1})
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got, want := restored.GetCurrentGameID(), dfc.GetCurrentGameID(); got != want {
+		t.Errorf("game id = %q, want %q", got, want)
+	}
+	if got, want := restored.GetCurrentState(), StateDrawing; got != want {
+		t.Errorf("state = %s, want %s", got, want)
+	}
+	if got, want := restored.GetDrawnBalls(), dfc.GetDrawnBalls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("drawn balls = %v, want %v", got, want)
+	}
+	if got, want := restored.GetStateHistory(), dfc.GetStateHistory(); !reflect.DeepEqual(got, want) {
+		t.Errorf("state history = %v, want %v", got, want)
+	}
+	if got, want := restored.GetLuckyNumbers(), dfc.GetLuckyNumbers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lucky numbers = %v, want %v", got, want)
+	}
+	if got, want := restored.GetJackpotAmount(), dfc.GetJackpotAmount(); got != want {
+		t.Errorf("jackpot amount = %v, want %v", got, want)
+	}
+
+	// 恢復後的抽球仍排除已抽出的球，且手動JP設定仍然有效
+	ball, err := restored.DrawBall()
+	if err != nil {
+		t.Fatalf("DrawBall after restore: %v", err)
+	}
+	for _, drawn := range dfc.GetDrawnBalls() {
+		if drawn.BallNumber == ball.BallNumber {
+			t.Fatalf("ball %d drawn again after restore", ball.BallNumber)
+		}
+	}
+	if ball.OrderIndex != 6 {
+		t.Errorf("order index after restore = %d, want 6", ball.OrderIndex)
+	}
+	if restored.GetGameStatus().Game.HasJackpot {
+		t.Error("HasJackpot = true after restore, want manual false to be kept")
+	}
+}
+
+func TestSnapshotRestoreResumesBettingCountdown(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{BettingDuration: 5 * time.Second})
+	moveTo(t, dfc, StateReady, StateBetting)
+	snapshot := roundTrip(t, dfc.Snapshot())
+	dfc.ChangeState(StateDrawing) // 停止原控制器的倒數
+
+	// 重啟花了兩秒，恢復後以剩餘的三秒繼續倒數
+	restored, clock, countdowns := newCountdownController(t, 5*time.Second)
+	clock.Advance(2 * time.Second)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	select {
+	case countdown := <-countdowns:
+		if countdown.Remaining != 3 {
+			t.Fatalf("remaining after restore = %d, want 3", countdown.Remaining)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no countdown after restoring a betting game")
+	}
+	clock.Advance(3 * time.Second)
+	waitForState(t, restored, StateDrawing)
+}
+
+func TestSnapshotRestoreRejectsInvalidSnapshot(t *testing.T) {
+	dfc, _ := newTestController(t, ControllerConfig{})
+
+	cases := map[string]*Snapshot{
+		"nil":           nil,
+		"version":       {Version: SnapshotVersion + 1, State: StateDrawing},
+		"unknown state": {Version: SnapshotVersion, State: "UNKNOWN"},
+		"ball range":    {Version: SnapshotVersion, State: StateDrawing, DrawnBalls: []DrawResult{{BallNumber: 76, OrderIndex: 1}}},
+	}
+	for name, snapshot := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := dfc.Restore(snapshot); !errors.Is(err, ErrInvalidArgument) {
+				t.Fatalf("Restore error = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+
+	if state := dfc.GetCurrentState(); state != StateAgent {
+		t.Fatalf("state after rejected restores = %s, want %s", state, StateAgent)
+	}
+}
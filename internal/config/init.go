@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -21,6 +22,14 @@ func initializeConfig() *Config {
 	cfg.Server.Port = 8080
 	cfg.Server.APIHost = "localhost:8080"
 	cfg.Server.Version = getEnv("VERSION", "1.0.0")
+	// 預設荷官端與玩家端使用獨立端口，開啟後改由 API 端口以路徑區分
+	cfg.Server.SharedPort = getEnvAsBool("SHARED_PORT", false)
+	cfg.Server.MaxConns = getEnvAsInt("MAX_CONNECTIONS", 1000)
+	cfg.Server.RejectDupDealer = getEnvAsBool("REJECT_DUPLICATE_DEALER", false)
+	cfg.Server.Env = getEnv("APP_ENV", EnvDevelopment)
+	// 荷官端與玩家端 WebSocket 端口，0 表示不啟動獨立服務器；Nacos 設定會覆蓋此值
+	cfg.Server.DealerWSPort = uint64(getEnvAsInt("DEALER_WS_PORT", 0))
+	cfg.Server.PlayerWSPort = uint64(getEnvAsInt("PLAYER_WS_PORT", 0))
 
 	// 數據庫設定（使用默認值，等待 Nacos 覆蓋）
 	// 默認 TiDB 連接參數
@@ -40,6 +49,54 @@ func initializeConfig() *Config {
 	cfg.JWT.Secret = "default-secret-key"
 	cfg.JWT.ExpiresIn = 24 * time.Hour
 
+	// 遊戲設定（使用默認值，等待 Nacos 覆蓋）
+	cfg.Game.LuckyNumberCount = getEnvAsInt("LUCKY_NUMBER_COUNT", 7)
+	cfg.Game.BallRandomSeed = getEnvAsInt64("BALL_RANDOM_SEED", 0)
+	cfg.Game.TotalBalls = getEnvAsInt("GAME_TOTAL_BALLS", 75)
+	cfg.Game.MainDrawCount = getEnvAsInt("GAME_MAIN_DRAW_COUNT", 30)
+	cfg.Game.JPDrawCount = getEnvAsInt("GAME_JP_DRAW_COUNT", 30)
+	cfg.Game.MaxExtraBalls = getEnvAsInt("GAME_MAX_EXTRA_BALLS", 3)
+	cfg.Game.AutoResetDelay = getEnvAsDuration("GAME_AUTO_RESET_DELAY", 0)
+	cfg.Game.BettingDuration = getEnvAsDuration("GAME_BETTING_DURATION", 0)
+	cfg.Game.RoomID = getEnv("GAME_ROOM_ID", "SG01")
+	cfg.Game.JackpotAmount = getEnvAsFloat64("GAME_JACKPOT_AMOUNT", 500000)
+	cfg.Game.JackpotIncrement = getEnvAsFloat64("GAME_JACKPOT_INCREMENT", 0)
+	// 快照恢復需明確啟用，避免既有部署在啟動時恢復 Redis 中過時的遊戲
+	cfg.Game.SnapshotEnabled = getEnvAsBool("GAME_SNAPSHOT_ENABLED", false)
+	cfg.Game.SnapshotKey = getEnv("GAME_SNAPSHOT_KEY", "g38:game:snapshot")
+	cfg.Game.SnapshotTTL = getEnvAsDuration("GAME_SNAPSHOT_TTL", 24*time.Hour)
+	cfg.Game.SnapshotFlushInterval = getEnvAsDuration("GAME_SNAPSHOT_FLUSH_INTERVAL", 0)
+
+	// WebSocket 連接讀寫設定（從環境變量讀取），未設置時保持 0，由荷官端與玩家端各自套用預設值
+	cfg.WebSocket.ReadLimit = getEnvAsInt64("WS_READ_LIMIT", 0)
+	cfg.WebSocket.ReadTimeout = getEnvAsDuration("WS_READ_TIMEOUT", 0)
+	cfg.WebSocket.PingInterval = getEnvAsDuration("WS_PING_INTERVAL", 0)
+	cfg.WebSocket.WriteTimeout = getEnvAsDuration("WS_WRITE_TIMEOUT", 0)
+	cfg.WebSocket.EnableCompression = getEnvAsBool("WS_ENABLE_COMPRESSION", false)
+	cfg.WebSocket.CompressionLevel = getEnvAsInt("WS_COMPRESSION_LEVEL", 0)
+	cfg.WebSocket.RoomIDPattern = getEnv("WS_ROOM_ID_PATTERN", "")
+	cfg.WebSocket.ResumeGracePeriod = getEnvAsDuration("WS_RESUME_GRACE_PERIOD", 0)
+	cfg.WebSocket.MaxSubscribersPerRoom = getEnvAsInt("WS_MAX_SUBSCRIBERS_PER_ROOM", 0)
+	cfg.WebSocket.MaxRoomSubscriptions = getEnvAsInt("WS_MAX_ROOM_SUBSCRIPTIONS", 0)
+
+	// CORS 設定（從環境變量讀取），開發環境預設允許所有來源，正式環境預設不允許跨來源請求
+	defaultOrigins := []string{"*"}
+	if cfg.Server.IsProduction() {
+		defaultOrigins = []string{}
+	}
+	cfg.CORS.AllowedOrigins = getEnvAsList("CORS_ALLOWED_ORIGINS", defaultOrigins)
+	cfg.CORS.AllowedMethods = getEnvAsList("CORS_ALLOWED_METHODS", []string{"POST", "OPTIONS", "GET", "PUT", "DELETE"})
+	cfg.CORS.AllowedHeaders = getEnvAsList("CORS_ALLOWED_HEADERS", []string{
+		"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization",
+		"accept", "origin", "Cache-Control", "X-Requested-With", "X-Request-ID",
+	})
+
+	// WebSocket 允許的來源，預設與 CORS 相同
+	cfg.WebSocket.AllowedOrigins = getEnvAsList("WS_ALLOWED_ORIGINS", cfg.CORS.AllowedOrigins)
+
+	// 關閉超時設定（從環境變量讀取）
+	cfg.Shutdown = loadShutdownConfig()
+
 	// Nacos 設定（從環境變量讀取）
 	cfg.EnableNacos = getEnvAsBool("ENABLE_NACOS", false)
 	cfg.Nacos.Host = getEnv("NACOS_HOST", "localhost")
@@ -54,6 +111,21 @@ func initializeConfig() *Config {
 	return cfg
 }
 
+// LoadShutdownConfig 讀取關閉超時設定，供 fx 應用啟動前設置 StopTimeout
+func LoadShutdownConfig() ShutdownConfig {
+	_ = godotenv.Load()
+	return loadShutdownConfig()
+}
+
+func loadShutdownConfig() ShutdownConfig {
+	return ShutdownConfig{
+		ReadinessGrace: getEnvAsDuration("SHUTDOWN_READINESS_GRACE", 5*time.Second),
+		DrainTimeout:   getEnvAsDuration("SHUTDOWN_DRAIN_TIMEOUT", 10*time.Second),
+		NotifyTimeout:  getEnvAsDuration("SHUTDOWN_NOTIFY_TIMEOUT", 5*time.Second),
+		CloseTimeout:   getEnvAsDuration("SHUTDOWN_CLOSE_TIMEOUT", 5*time.Second),
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -70,6 +142,40 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat64(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList 讀取以逗號分隔的環境變量，忽略空白項目
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -78,3 +184,12 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebSocketLimitsZeroWhenUnset(t *testing.T) {
+	for _, key := range []string{"WS_READ_LIMIT", "WS_READ_TIMEOUT", "WS_PING_INTERVAL", "WS_WRITE_TIMEOUT", "WS_RESUME_GRACE_PERIOD"} {
+		t.Setenv(key, "")
+	}
+
+	// 未設置時保持 0，由荷官端與玩家端各自套用預設值
+	ws := initializeConfig().WebSocket
+	if ws.ReadLimit != 0 || ws.ReadTimeout != 0 || ws.PingInterval != 0 || ws.WriteTimeout != 0 || ws.ResumeGracePeriod != 0 {
+		t.Fatalf("websocket config = %+v, want zero limits when the environment is unset", ws)
+	}
+}
+
+func TestWebSocketLimitsFromEnv(t *testing.T) {
+	t.Setenv("WS_READ_LIMIT", "2048")
+	t.Setenv("WS_READ_TIMEOUT", "45s")
+	t.Setenv("WS_PING_INTERVAL", "20s")
+
+	ws := initializeConfig().WebSocket
+	if ws.ReadLimit != 2048 || ws.ReadTimeout != 45*time.Second || ws.PingInterval != 20*time.Second {
+		t.Fatalf("websocket config = %+v, want the limits from the environment", ws)
+	}
+}
+
+func TestGameBallCountsFromEnv(t *testing.T) {
+	t.Setenv("GAME_TOTAL_BALLS", "45")
+	t.Setenv("GAME_MAIN_DRAW_COUNT", "20")
+	t.Setenv("GAME_JP_DRAW_COUNT", "40")
+	t.Setenv("GAME_MAX_EXTRA_BALLS", "2")
+
+	game := initializeConfig().Game
+	if game.TotalBalls != 45 || game.MainDrawCount != 20 || game.JPDrawCount != 40 || game.MaxExtraBalls != 2 {
+		t.Fatalf("game config = %+v, want ball counts from the environment", game)
+	}
+}
+
+func TestGameBallCountsDefaults(t *testing.T) {
+	for _, key := range []string{"GAME_TOTAL_BALLS", "GAME_MAIN_DRAW_COUNT", "GAME_JP_DRAW_COUNT", "GAME_MAX_EXTRA_BALLS"} {
+		t.Setenv(key, "")
+	}
+
+	game := initializeConfig().Game
+	if game.TotalBalls != 75 || game.MainDrawCount != 30 || game.JPDrawCount != 30 || game.MaxExtraBalls != 3 {
+		t.Fatalf("game config = %+v, want default 75/30/30/3", game)
+	}
+}
+
+func TestLuckyNumberCountFromEnv(t *testing.T) {
+	t.Setenv("LUCKY_NUMBER_COUNT", "10")
+
+	if count := initializeConfig().Game.LuckyNumberCount; count != 10 {
+		t.Fatalf("lucky number count = %d, want 10 from the environment", count)
+	}
+}
+
+func TestBallRandomSeedFromEnv(t *testing.T) {
+	t.Setenv("BALL_RANDOM_SEED", "")
+	if seed := initializeConfig().Game.BallRandomSeed; seed != 0 {
+		t.Fatalf("ball random seed = %d when unset, want 0", seed)
+	}
+
+	t.Setenv("BALL_RANDOM_SEED", "42")
+	if seed := initializeConfig().Game.BallRandomSeed; seed != 42 {
+		t.Fatalf("ball random seed = %d, want 42 from the environment", seed)
+	}
+}
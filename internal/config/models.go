@@ -9,18 +9,44 @@ type Config struct {
 	Database    DatabaseConfig
 	Redis       RedisConfig
 	JWT         JWTConfig
+	Game        GameConfig
+	Shutdown    ShutdownConfig
+	WebSocket   WebSocketConfig
+	CORS        CORSConfig
 	Nacos       NacosConfig
 	EnableNacos bool
 }
 
 type ServerConfig struct {
-	Host         string
-	Port         uint64
-	DealerWSPort uint64 // 荷官端 WebSocket 端口
-	PlayerWSPort uint64 // 玩家端 WebSocket 端口 (預留)
-	APIHost      string
-	Version      string
-}
+	Host            string
+	Port            uint64
+	DealerWSPort    uint64 // 荷官端 WebSocket 端口
+	PlayerWSPort    uint64 // 玩家端 WebSocket 端口 (預留)
+	SharedPort      bool   // 是否將荷官端與玩家端 WebSocket 合併到 API 端口，以路徑區分
+	MaxConns        int    // 單一實例可承載的 WebSocket 連接數上限，用於估算剩餘容量
+	RejectDupDealer bool   // 是否拒絕第二個荷官連接，否則僅記錄警告
+	Env             string // 運行環境，development 或 production
+	APIHost         string
+	Version         string
+}
+
+// CORSConfig 跨來源請求設定
+type CORSConfig struct {
+	AllowedOrigins []string // 允許的來源，"*" 表示允許所有來源
+	AllowedMethods []string // 允許的 HTTP 方法
+	AllowedHeaders []string // 允許的請求標頭
+}
+
+// IsProduction 判斷是否為正式環境
+func (c ServerConfig) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// 運行環境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
 
 type DatabaseConfig struct {
 	Host     string
@@ -42,6 +68,63 @@ type JWTConfig struct {
 	ExpiresIn time.Duration
 }
 
+type GameConfig struct {
+	LuckyNumberCount int   // 幸運號碼數量
+	BallRandomSeed   int64 // 抽球隨機種子，0 表示使用加密隨機源；僅供測試與稽核重現使用
+
+	TotalBalls    int // 球池總球數，號碼為 1 到 TotalBalls
+	MainDrawCount int // 主遊戲抽球數
+	JPDrawCount   int // JP遊戲抽球數
+	MaxExtraBalls int // 最大額外球數
+
+	AutoResetDelay  time.Duration // 結算後自動開始下一局的延遲，0 表示停用
+	BettingDuration time.Duration // 投注階段時長，結束後自動開始抽球，0 表示停用
+
+	RoomID string // 本實例遊戲所在的房間ID，玩家端訂閱此房間以接收遊戲事件
+
+	JackpotAmount    float64 // JP基礎獎金金額
+	JackpotIncrement float64 // 每局未中JP時累積的獎金，0 表示固定金額
+
+	SnapshotEnabled bool          // 是否將遊戲快照持久化到 Redis 並於啟動時恢復，預設關閉
+	SnapshotKey     string        // 遊戲快照的 Redis 鍵
+	SnapshotTTL     time.Duration // 遊戲快照的過期時間，應大於一局遊戲的最長時間
+
+	SnapshotFlushInterval time.Duration // 同一狀態內快照的最短寫入間隔，0 表示每次變更都立即寫入
+}
+
+// WebSocketConfig WebSocket 連接讀寫限制與超時設定，荷官端與玩家端共用，數值為 0 時使用各端的預設值
+type WebSocketConfig struct {
+	ReadLimit    int64         // 單條訊息最大讀取大小（位元組）
+	ReadTimeout  time.Duration // 讀取超時
+	PingInterval time.Duration // 心跳（ping）發送間隔
+	WriteTimeout time.Duration // 寫入超時
+
+	EnableCompression bool // 是否啟用 permessage-deflate 壓縮
+	CompressionLevel  int  // 壓縮等級（-2 至 9），0 使用預設等級
+
+	RoomIDPattern     string        // 玩家端房間ID格式（正則表達式），空值使用預設格式
+	ResumeGracePeriod time.Duration // 玩家端斷線後保留房間訂閱以供重連恢復的時間
+
+	AllowedOrigins []string // 允許建立 WebSocket 連接的來源，"*" 表示允許所有來源
+
+	MaxSubscribersPerRoom int // 玩家端單一房間的訂閱上限，0 表示不限制
+	MaxRoomSubscriptions  int // 玩家端所有房間的訂閱總數上限，0 表示不限制
+}
+
+// ShutdownConfig 各關閉階段的超時設定
+type ShutdownConfig struct {
+	ReadinessGrace time.Duration // 標記為未就緒後等待負載均衡器停止轉發的時間
+	DrainTimeout   time.Duration // HTTP 服務器排空進行中請求的時限
+	NotifyTimeout  time.Duration // 通知 WebSocket 客戶端關閉的時限
+	CloseTimeout   time.Duration // 關閉數據庫與 Redis 連接的時限
+}
+
+// StopTimeout 返回整體關閉所需的時限，供 fx.StopTimeout 使用
+func (c ShutdownConfig) StopTimeout() time.Duration {
+	// 額外保留時間給其他生命週期鉤子
+	return c.ReadinessGrace + c.DrainTimeout + c.NotifyTimeout + c.CloseTimeout + 5*time.Second
+}
+
 type NacosConfig struct {
 	Host        string
 	Port        uint64
@@ -71,6 +154,10 @@ type NacosAppConfig struct {
 	RedisUsername  string `json:"REDIS_USERNAME" yaml:"REDIS_USERNAME"`
 	RedisPassword  string `json:"REDIS_PASSWORD" yaml:"REDIS_PASSWORD"`
 	RedisDB        int    `json:"REDIS_DB" yaml:"REDIS_DB"`
+	LuckyNumCount  int    `json:"LUCKY_NUMBER_COUNT" yaml:"LUCKY_NUMBER_COUNT"`
+
+	JackpotAmount    float64 `json:"JACKPOT_AMOUNT" yaml:"JACKPOT_AMOUNT"`
+	JackpotIncrement float64 `json:"JACKPOT_INCREMENT" yaml:"JACKPOT_INCREMENT"`
 }
 
 func (c *Config) GetDatabaseHost() string {
@@ -114,3 +201,8 @@ func (c *Config) GetDealerWSPort() uint64 {
 func (c *Config) GetPlayerWSPort() uint64 {
 	return c.Server.PlayerWSPort
 }
+
+// 是否使用共用端口
+func (c *Config) IsSharedPort() bool {
+	return c.Server.SharedPort
+}
@@ -21,6 +21,21 @@ var Module = fx.Module("config",
 )
 
 func ProvideConfig(lc fx.Lifecycle, nacosClient nacosManager.NacosClient, logger logger.Logger) (*Config, error) {
+	cfg, err := loadConfig(lc, nacosClient, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置不一致時拒絕啟動
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// loadConfig 載入本地配置，啟用 Nacos 時再以 Nacos 配置覆蓋
+func loadConfig(lc fx.Lifecycle, nacosClient nacosManager.NacosClient, logger logger.Logger) (*Config, error) {
 	cfg := initializeConfig()
 
 	logger.Info(fmt.Sprintf("Nacos配置: Host=%s, Port=%d, Namespace=%s, Group=%s, DataId=%s, EnableNacos=%v",
@@ -116,6 +131,22 @@ func extractConfig(jsonStr string, logger logger.Logger) *NacosAppConfig {
 			config.RedisDB = redisDB
 		}
 	}
+	luckyNumCountStr := extractStringValue(cleanStr, `"LUCKY_NUMBER_COUNT":\s*(\d+)`)
+	if luckyNumCountStr != "" {
+		if luckyNumCount, err := strconv.Atoi(luckyNumCountStr); err == nil {
+			config.LuckyNumCount = luckyNumCount
+		}
+	}
+	if jackpotAmountStr := extractStringValue(cleanStr, `"JACKPOT_AMOUNT":\s*([\d.]+)`); jackpotAmountStr != "" {
+		if jackpotAmount, err := strconv.ParseFloat(jackpotAmountStr, 64); err == nil {
+			config.JackpotAmount = jackpotAmount
+		}
+	}
+	if jackpotIncrementStr := extractStringValue(cleanStr, `"JACKPOT_INCREMENT":\s*([\d.]+)`); jackpotIncrementStr != "" {
+		if jackpotIncrement, err := strconv.ParseFloat(jackpotIncrementStr, 64); err == nil {
+			config.JackpotIncrement = jackpotIncrement
+		}
+	}
 
 	// 列出提取的值，便於調試
 	logger.Info(fmt.Sprintf("手動提取的配置: PORT=%s, DB_HOST=%s, DB_PORT=%d, DB_NAME=%s, DB_USER=%s",
@@ -223,6 +254,22 @@ func updateConfigFromExtracted(cfg *Config, nacosConfig *NacosAppConfig, logger
 		logger.Info(fmt.Sprintf("更新Redis數據庫: %d -> %d", cfg.Redis.DB, nacosConfig.RedisDB))
 		cfg.Redis.DB = nacosConfig.RedisDB
 	}
+
+	// 更新遊戲配置
+	if nacosConfig.LuckyNumCount != 0 {
+		logger.Info(fmt.Sprintf("更新幸運號碼數量: %d -> %d", cfg.Game.LuckyNumberCount, nacosConfig.LuckyNumCount))
+		cfg.Game.LuckyNumberCount = nacosConfig.LuckyNumCount
+	}
+
+	if nacosConfig.JackpotAmount != 0 {
+		logger.Info(fmt.Sprintf("更新JP基礎獎金: %.2f -> %.2f", cfg.Game.JackpotAmount, nacosConfig.JackpotAmount))
+		cfg.Game.JackpotAmount = nacosConfig.JackpotAmount
+	}
+
+	if nacosConfig.JackpotIncrement != 0 {
+		logger.Info(fmt.Sprintf("更新JP每局累積獎金: %.2f -> %.2f", cfg.Game.JackpotIncrement, nacosConfig.JackpotIncrement))
+		cfg.Game.JackpotIncrement = nacosConfig.JackpotIncrement
+	}
 }
 
 // removeJSONComments 使用正則表達式移除JSON字符串中的JavaScript樣式註解
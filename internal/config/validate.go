@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate 檢查配置的一致性，返回所有不合法設定合併後的錯誤
+func (c *Config) Validate() error {
+	var errs []error
+
+	game := c.Game
+	if game.TotalBalls <= 0 {
+		errs = append(errs, fmt.Errorf("game total balls must be positive, got %d", game.TotalBalls))
+	}
+	if game.MainDrawCount <= 0 {
+		errs = append(errs, fmt.Errorf("game main draw count must be positive, got %d", game.MainDrawCount))
+	}
+	if game.JPDrawCount <= 0 {
+		errs = append(errs, fmt.Errorf("game JP draw count must be positive, got %d", game.JPDrawCount))
+	}
+	if game.MaxExtraBalls <= 0 {
+		errs = append(errs, fmt.Errorf("game max extra balls must be positive, got %d", game.MaxExtraBalls))
+	}
+	if game.LuckyNumberCount <= 0 {
+		errs = append(errs, fmt.Errorf("game lucky number count must be positive, got %d", game.LuckyNumberCount))
+	}
+
+	if game.TotalBalls > 0 {
+		if game.MainDrawCount+game.MaxExtraBalls > game.TotalBalls {
+			errs = append(errs, fmt.Errorf("game main draw count %d plus max extra balls %d exceeds total balls %d",
+				game.MainDrawCount, game.MaxExtraBalls, game.TotalBalls))
+		}
+		if game.JPDrawCount > game.TotalBalls {
+			errs = append(errs, fmt.Errorf("game JP draw count %d exceeds total balls %d",
+				game.JPDrawCount, game.TotalBalls))
+		}
+		if game.LuckyNumberCount > game.TotalBalls {
+			errs = append(errs, fmt.Errorf("game lucky number count %d exceeds total balls %d",
+				game.LuckyNumberCount, game.TotalBalls))
+		}
+	}
+
+	if game.JackpotAmount < 0 {
+		errs = append(errs, fmt.Errorf("game jackpot amount must not be negative, got %.2f", game.JackpotAmount))
+	}
+	if game.JackpotIncrement < 0 {
+		errs = append(errs, fmt.Errorf("game jackpot increment must not be negative, got %.2f", game.JackpotIncrement))
+	}
+
+	switch c.Server.Env {
+	case EnvDevelopment, EnvProduction:
+	default:
+		errs = append(errs, fmt.Errorf("server env must be %s or %s, got %q", EnvDevelopment, EnvProduction, c.Server.Env))
+	}
+	// 獨立端口模式下，各服務器端口不可相同
+	if !c.Server.SharedPort {
+		if c.Server.DealerWSPort > 0 && c.Server.DealerWSPort == c.Server.Port {
+			errs = append(errs, fmt.Errorf("dealer WebSocket port %d conflicts with API port", c.Server.DealerWSPort))
+		}
+		if c.Server.PlayerWSPort > 0 && (c.Server.PlayerWSPort == c.Server.Port || c.Server.PlayerWSPort == c.Server.DealerWSPort) {
+			errs = append(errs, fmt.Errorf("player WebSocket port %d conflicts with another server port", c.Server.PlayerWSPort))
+		}
+	}
+	if c.Server.IsProduction() {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				errs = append(errs, fmt.Errorf("CORS wildcard origin is not allowed in %s", EnvProduction))
+				break
+			}
+		}
+		for _, origin := range c.WebSocket.AllowedOrigins {
+			if origin == "*" {
+				errs = append(errs, fmt.Errorf("WebSocket wildcard origin is not allowed in %s", EnvProduction))
+				break
+			}
+		}
+	}
+
+	if c.WebSocket.MaxSubscribersPerRoom < 0 {
+		errs = append(errs, fmt.Errorf("websocket max subscribers per room must not be negative, got %d", c.WebSocket.MaxSubscribersPerRoom))
+	}
+	if c.WebSocket.MaxRoomSubscriptions < 0 {
+		errs = append(errs, fmt.Errorf("websocket max room subscriptions must not be negative, got %d", c.WebSocket.MaxRoomSubscriptions))
+	}
+
+	// 房間ID格式由玩家端 WebSocket 管理器於啟動時檢查
+	if game.RoomID == "" {
+		errs = append(errs, fmt.Errorf("game room id is required"))
+	}
+
+	if game.SnapshotFlushInterval < 0 {
+		errs = append(errs, fmt.Errorf("game snapshot flush interval must not be negative, got %v", game.SnapshotFlushInterval))
+	}
+	if game.SnapshotEnabled && game.SnapshotKey == "" {
+		errs = append(errs, fmt.Errorf("game snapshot key is required when snapshots are enabled"))
+	}
+
+	return errors.Join(errs...)
+}
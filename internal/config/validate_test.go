@@ -0,0 +1,164 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig 返回可通過檢查的配置，各測試在此基礎上修改單一欄位
+func validConfig() *Config {
+	cfg := &Config{}
+	cfg.Server.Env = EnvDevelopment
+	cfg.Server.Port = 8080
+	cfg.Server.DealerWSPort = 8081
+	cfg.Server.PlayerWSPort = 8082
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.WebSocket.AllowedOrigins = []string{"*"}
+
+	cfg.Game.TotalBalls = 75
+	cfg.Game.MainDrawCount = 30
+	cfg.Game.JPDrawCount = 30
+	cfg.Game.MaxExtraBalls = 3
+	cfg.Game.LuckyNumberCount = 7
+	cfg.Game.JackpotAmount = 500000
+	cfg.Game.RoomID = "SG01"
+	cfg.Game.SnapshotKey = "g38:game:snapshot"
+	return cfg
+}
+
+// joinedErrors 展開 errors.Join 合併的錯誤
+func joinedErrors(t *testing.T, err error) []error {
+	t.Helper()
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("error %v is not joined", err)
+	}
+	return joined.Unwrap()
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	// 正式環境使用明確的來源白名單
+	cfg := validConfig()
+	cfg.Server.Env = EnvProduction
+	cfg.CORS.AllowedOrigins = []string{"https://dealer.example.com"}
+	cfg.WebSocket.AllowedOrigins = []string{"https://dealer.example.com"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() in production = %v, want nil", err)
+	}
+}
+
+func TestValidateRejectsInvalidField(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{"total balls", func(cfg *Config) { cfg.Game.TotalBalls = 0 }, "game total balls must be positive"},
+		{"main draw count", func(cfg *Config) { cfg.Game.MainDrawCount = 0 }, "game main draw count must be positive"},
+		{"JP draw count", func(cfg *Config) { cfg.Game.JPDrawCount = 0 }, "game JP draw count must be positive"},
+		{"JP draws exceed pool", func(cfg *Config) { cfg.Game.JPDrawCount = 76 }, "game JP draw count 76 exceeds total balls 75"},
+		{"max extra balls", func(cfg *Config) { cfg.Game.MaxExtraBalls = -1 }, "game max extra balls must be positive"},
+		{"lucky number count", func(cfg *Config) { cfg.Game.LuckyNumberCount = 0 }, "game lucky number count must be positive"},
+		{"draws exceed pool", func(cfg *Config) { cfg.Game.MainDrawCount = 73 }, "game main draw count 73 plus max extra balls 3 exceeds total balls 75"},
+		{"lucky numbers exceed pool", func(cfg *Config) { cfg.Game.LuckyNumberCount = 76 }, "game lucky number count 76 exceeds total balls 75"},
+		{"jackpot amount", func(cfg *Config) { cfg.Game.JackpotAmount = -1 }, "game jackpot amount must not be negative"},
+		{"jackpot increment", func(cfg *Config) { cfg.Game.JackpotIncrement = -1 }, "game jackpot increment must not be negative"},
+		{"room id", func(cfg *Config) { cfg.Game.RoomID = "" }, "game room id is required"},
+		{"snapshot flush interval", func(cfg *Config) { cfg.Game.SnapshotFlushInterval = -time.Second }, "game snapshot flush interval must not be negative"},
+		{"snapshot key", func(cfg *Config) { cfg.Game.SnapshotEnabled = true; cfg.Game.SnapshotKey = "" }, "game snapshot key is required"},
+		{"server env", func(cfg *Config) { cfg.Server.Env = "staging" }, "server env must be"},
+		{"dealer port", func(cfg *Config) { cfg.Server.DealerWSPort = cfg.Server.Port }, "dealer WebSocket port 8080 conflicts with API port"},
+		{"player port", func(cfg *Config) { cfg.Server.PlayerWSPort = cfg.Server.DealerWSPort }, "player WebSocket port 8081 conflicts"},
+		{"cors wildcard in production", func(cfg *Config) {
+			cfg.Server.Env = EnvProduction
+			cfg.WebSocket.AllowedOrigins = []string{"https://dealer.example.com"}
+		}, "CORS wildcard origin is not allowed"},
+		{"websocket wildcard in production", func(cfg *Config) {
+			cfg.Server.Env = EnvProduction
+			cfg.CORS.AllowedOrigins = []string{"https://dealer.example.com"}
+		}, "WebSocket wildcard origin is not allowed"},
+		{"subscribers per room", func(cfg *Config) { cfg.WebSocket.MaxSubscribersPerRoom = -1 }, "websocket max subscribers per room must not be negative"},
+		{"room subscriptions", func(cfg *Config) { cfg.WebSocket.MaxRoomSubscriptions = -1 }, "websocket max room subscriptions must not be negative"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.modify(cfg)
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatalf("Validate() = nil, want error containing %q", tc.want)
+			}
+			if errs := joinedErrors(t, err); len(errs) != 1 || !strings.Contains(errs[0].Error(), tc.want) {
+				t.Fatalf("Validate() = %v, want a single error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateSharedPortAllowsSamePorts(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.SharedPort = true
+	cfg.Server.DealerWSPort = cfg.Server.Port
+	cfg.Server.PlayerWSPort = cfg.Server.Port
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() with shared port = %v, want nil", err)
+	}
+}
+
+func TestValidateReportsAllFailures(t *testing.T) {
+	cfg := validConfig()
+	cfg.Game.MainDrawCount = 0
+	cfg.Game.JackpotIncrement = -1
+	cfg.Server.Env = "staging"
+	cfg.Game.RoomID = ""
+
+	err := cfg.Validate()
+	if errs := joinedErrors(t, err); len(errs) != 4 {
+		t.Fatalf("Validate() reported %d errors, want 4: %v", len(errs), err)
+	}
+	for _, want := range []string{"main draw count", "jackpot increment", "server env", "room id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestValidateBallCountsFitPool(t *testing.T) {
+	cases := []struct {
+		name                      string
+		total, main, extra, lucky int
+		valid                     bool
+	}{
+		{name: "draws fill the pool exactly", total: 33, main: 30, extra: 3, lucky: 7, valid: true},
+		{name: "extra balls overflow the pool", total: 32, main: 30, extra: 3, lucky: 7, valid: false},
+		{name: "main draw alone exceeds the pool", total: 20, main: 30, extra: 1, lucky: 7, valid: false},
+		{name: "lucky numbers fill the pool exactly", total: 33, main: 30, extra: 3, lucky: 33, valid: true},
+		{name: "15 ball variant", total: 15, main: 10, extra: 3, lucky: 5, valid: true},
+		{name: "45 ball variant", total: 45, main: 20, extra: 5, lucky: 6, valid: true},
+		{name: "15 ball pool with default draw counts", total: 15, main: 30, extra: 3, lucky: 7, valid: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Game.TotalBalls = tc.total
+			cfg.Game.MainDrawCount = tc.main
+			cfg.Game.MaxExtraBalls = tc.extra
+			cfg.Game.LuckyNumberCount = tc.lucky
+			cfg.Game.JPDrawCount = tc.total
+
+			if err := cfg.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("Validate() = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 
 	"g38_lottery_service/game"
 	"g38_lottery_service/internal/service"
+	"g38_lottery_service/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -49,6 +51,12 @@ func (h *GameHandler) GetGameState(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"state": string(state)})
 }
 
+// ChangeStateResponse 狀態更改回應，附帶轉換後的遊戲狀態快照
+type ChangeStateResponse struct {
+	Message string                   `json:"message"`
+	Status  *game.GameStatusResponse `json:"status"`
+}
+
 // ChangeGameState 更改遊戲狀態
 // @Summary 更改遊戲狀態
 // @Description 更改當前遊戲狀態
@@ -56,8 +64,9 @@ func (h *GameHandler) GetGameState(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param data body map[string]string true "狀態信息"
-// @Success 200 {object} SuccessResponse "狀態更改成功"
+// @Success 200 {object} ChangeStateResponse "狀態更改成功"
 // @Failure 400 {object} ErrorResponse "請求錯誤"
+// @Failure 409 {object} ErrorResponse "當前狀態不允許此轉換"
 // @Failure 500 {object} ErrorResponse "服務器錯誤"
 // @Router /api/v1/game/state [post]
 func (h *GameHandler) ChangeGameState(c *gin.Context) {
@@ -70,11 +79,94 @@ func (h *GameHandler) ChangeGameState(c *gin.Context) {
 		return
 	}
 
-	err := h.gameService.ChangeState(game.GameState(req.State))
+	status, err := h.gameService.ChangeStateAndGetStatus(game.GameState(req.State))
 	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+	logRequest(c, "遊戲 %s 狀態已更改為 %s", status.Game.ID, req.State)
+
+	c.JSON(http.StatusOK, ChangeStateResponse{Message: "遊戲狀態已更改", Status: status})
+}
+
+// UndoLastBall 撤銷最後一顆球
+// @Summary 撤銷最後一顆球
+// @Description 撤銷指定類型最後抽出的一顆球，僅允許在該類型的抽球階段內進行
+// @Tags game
+// @Accept json
+// @Produce json
+// @Param data body map[string]string true "球類型 (REGULAR 或 EXTRA)"
+// @Success 200 {object} game.DrawResult "被撤銷的球"
+// @Failure 400 {object} ErrorResponse "請求錯誤"
+// @Failure 409 {object} ErrorResponse "當前狀態不允許撤銷或沒有可撤銷的球"
+// @Router /api/v1/game/undo-ball [post]
+func (h *GameHandler) UndoLastBall(c *gin.Context) {
+	var req struct {
+		BallType string `json:"ballType" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "遊戲狀態已更改"})
+	removed, err := h.gameService.UndoLastBall(game.BallType(req.BallType))
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+	logRequest(c, "已撤銷 %s 球 %d", req.BallType, removed.BallNumber)
+
+	c.JSON(http.StatusOK, removed)
+}
+
+// SetLuckyNumbers 設置幸運號碼
+// @Summary 設置幸運號碼
+// @Description 設置幸運號碼（JP觸發號碼），未提供號碼時由服務端隨機產生
+// @Tags game
+// @Accept json
+// @Produce json
+// @Param data body map[string][]int false "幸運號碼"
+// @Success 200 {object} map[string][]int "幸運號碼"
+// @Failure 400 {object} ErrorResponse "請求錯誤"
+// @Router /api/v1/game/lucky-numbers [post]
+func (h *GameHandler) SetLuckyNumbers(c *gin.Context) {
+	var req struct {
+		Numbers []int `json:"numbers"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if len(req.Numbers) == 0 {
+		numbers, err := h.gameService.GenerateLuckyNumbers()
+		if err != nil {
+			respondGameError(c, err)
+			return
+		}
+		logRequest(c, "已產生幸運號碼 %v", numbers)
+		c.JSON(http.StatusOK, gin.H{"luckyNumbers": numbers})
+		return
+	}
+
+	if err := h.gameService.SetJPTriggerNumbers(req.Numbers); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	logRequest(c, "已設置幸運號碼 %v", req.Numbers)
+
+	c.JSON(http.StatusOK, gin.H{"luckyNumbers": h.gameService.GetLuckyNumbers()})
+}
+
+// respondGameError 依遊戲錯誤分類返回對應的 HTTP 狀態碼與錯誤代碼
+func respondGameError(c *gin.Context, err error) {
+	logRequest(c, "%s %s 失敗: %v", c.Request.Method, c.Request.URL.Path, err)
+	c.JSON(game.HTTPStatus(err), ErrorResponse{Error: err.Error(), Code: game.ErrorCode(err)})
+}
+
+// logRequest 記錄帶有請求ID的處理日誌，便於與存取日誌及客戶端回報的 X-Request-ID 對照
+func logRequest(c *gin.Context, format string, args ...interface{}) {
+	log.Printf("[%s] "+format, append([]interface{}{middleware.GetRequestID(c.Request.Context())}, args...)...)
 }
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/service"
+	"g38_lottery_service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeGameService 以真實控制器實現遊戲處理器與荷官消息處理器使用的方法，未實現的方法被調用時會 panic
+type fakeGameService struct {
+	service.GameService
+
+	controller *game.DataFlowController
+}
+
+func (s *fakeGameService) GetGameStatus() *game.GameStatusResponse {
+	return s.controller.GetGameStatus()
+}
+
+func (s *fakeGameService) GetCurrentState() game.GameState {
+	return s.controller.GetCurrentState()
+}
+
+func (s *fakeGameService) ChangeStateAndGetStatus(state game.GameState) (*game.GameStatusResponse, error) {
+	return s.controller.ChangeStateAndGetStatus(state)
+}
+
+func (s *fakeGameService) UndoLastBall(ballType game.BallType) (*game.DrawResult, error) {
+	return s.controller.UndoLastBall(ballType)
+}
+
+func (s *fakeGameService) SetJPTriggerNumbers(numbers []int) error {
+	return s.controller.SetJPTriggerNumbers(numbers)
+}
+
+func (s *fakeGameService) GenerateLuckyNumbers() ([]int, error) {
+	return s.controller.GenerateLuckyNumbers()
+}
+
+func (s *fakeGameService) GetLuckyNumbers() []int {
+	return s.controller.GetLuckyNumbers()
+}
+
+func (s *fakeGameService) DrawJPBall() (*game.DrawResult, error) {
+	return s.controller.DrawJPBall()
+}
+
+// newFakeGameService 創建遊戲服務替身並依序轉換到指定狀態
+func newFakeGameService(t *testing.T, states ...game.GameState) *fakeGameService {
+	t.Helper()
+
+	controller, err := game.NewDataFlowController(&game.ControllerConfig{RandomSource: game.NewSeededBallSource(1)})
+	if err != nil {
+		t.Fatalf("NewDataFlowController: %v", err)
+	}
+	for _, state := range states {
+		if err := controller.ChangeState(state); err != nil {
+			t.Fatalf("ChangeState(%s): %v", state, err)
+		}
+	}
+	return &fakeGameService{controller: controller}
+}
+
+// performGameRequest 經由遊戲相關路由發送請求，body 為 nil 時不帶請求內容
+func performGameRequest(t *testing.T, gameService service.GameService, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	api := r.Group("/api/v1")
+	gameHandler := NewGameHandler(gameService)
+	configurePublicRoutes(api, gameHandler, nil)
+	configureAuthenticatedRoutes(api, gameHandler)
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeErrorResponse 解析錯誤回應
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode error response %q: %v", w.Body.String(), err)
+	}
+	return response
+}
+
+func TestUndoLastBallHandlerRemovesLastBall(t *testing.T) {
+	gameService := newFakeGameService(t, game.StateReady, game.StateBetting, game.StateDrawing)
+	var last *game.DrawResult
+	for i := 0; i < 2; i++ {
+		result, err := gameService.controller.DrawBall()
+		if err != nil {
+			t.Fatalf("DrawBall: %v", err)
+		}
+		last = result
+	}
+
+	w := performGameRequest(t, gameService, http.MethodPost, "/api/v1/game/undo-ball", map[string]string{"ballType": "REGULAR"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s, want 200", w.Code, w.Body.String())
+	}
+
+	var removed game.DrawResult
+	if err := json.Unmarshal(w.Body.Bytes(), &removed); err != nil {
+		t.Fatalf("decode removed ball: %v", err)
+	}
+	if removed.BallNumber != last.BallNumber || removed.OrderIndex != last.OrderIndex {
+		t.Fatalf("removed = %+v, want the last drawn ball %+v", removed, last)
+	}
+	if count := len(gameService.controller.GetDrawnBalls()); count != 1 {
+		t.Fatalf("drawn balls after undo = %d, want 1", count)
+	}
+}
+
+func TestUndoLastBallHandlerErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		states     []game.GameState
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "regular ball outside drawing",
+			states:     []game.GameState{game.StateReady, game.StateBetting},
+			body:       map[string]string{"ballType": "REGULAR"},
+			wantStatus: http.StatusConflict,
+			wantCode:   "INVALID_STATE",
+		},
+		{
+			name:       "extra ball outside extra draw",
+			states:     []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing},
+			body:       map[string]string{"ballType": "EXTRA"},
+			wantStatus: http.StatusConflict,
+			wantCode:   "INVALID_STATE",
+		},
+		{
+			name:       "nothing to undo",
+			states:     []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing},
+			body:       map[string]string{"ballType": "REGULAR"},
+			wantStatus: http.StatusConflict,
+			wantCode:   "NOTHING_TO_UNDO",
+		},
+		{
+			name:       "unknown ball type",
+			states:     []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing},
+			body:       map[string]string{"ballType": "BONUS"},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_ARGUMENT",
+		},
+		{
+			name:       "missing ball type",
+			states:     []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing},
+			body:       map[string]string{},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gameService := newFakeGameService(t, tc.states...)
+
+			w := performGameRequest(t, gameService, http.MethodPost, "/api/v1/game/undo-ball", tc.body)
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, body %s, want %d", w.Code, w.Body.String(), tc.wantStatus)
+			}
+			if response := decodeErrorResponse(t, w); response.Code != tc.wantCode {
+				t.Fatalf("error code = %q, want %q", response.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestSetLuckyNumbersRejectsInvalidSet(t *testing.T) {
+	cases := map[string][]int{
+		"duplicate":    {1, 2, 3, 4, 5, 6, 6},
+		"out of range": {1, 2, 3, 4, 5, 6, 76},
+		"wrong count":  {1, 2, 3},
+	}
+
+	for name, numbers := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := performGameRequest(t, newFakeGameService(t), http.MethodPost, "/api/v1/game/lucky-numbers", map[string][]int{"numbers": numbers})
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, body %s, want 400", w.Code, w.Body.String())
+			}
+			if response := decodeErrorResponse(t, w); response.Code != "INVALID_ARGUMENT" {
+				t.Fatalf("error code = %q, want INVALID_ARGUMENT", response.Code)
+			}
+		})
+	}
+}
+
+func TestSetLuckyNumbersGeneratesWhenEmpty(t *testing.T) {
+	w := performGameRequest(t, newFakeGameService(t), http.MethodPost, "/api/v1/game/lucky-numbers", map[string][]int{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s, want 200", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		LuckyNumbers []int `json:"luckyNumbers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	seen := make(map[int]bool)
+	for _, number := range response.LuckyNumbers {
+		if number < 1 || number > 75 || seen[number] {
+			t.Fatalf("generated lucky numbers = %v, want unique numbers in 1-75", response.LuckyNumbers)
+		}
+		seen[number] = true
+	}
+	if len(seen) != 7 {
+		t.Fatalf("generated lucky numbers = %v, want 7 numbers", response.LuckyNumbers)
+	}
+}
+
+func TestRespondGameError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		kind       error
+		wantStatus int
+		wantCode   string
+	}{
+		{game.ErrInvalidState, http.StatusConflict, "INVALID_STATE"},
+		{game.ErrInvalidTransition, http.StatusConflict, "INVALID_TRANSITION"},
+		{game.ErrBallsExhausted, http.StatusConflict, "BALLS_EXHAUSTED"},
+		{game.ErrNothingToUndo, http.StatusConflict, "NOTHING_TO_UNDO"},
+		{game.ErrInvalidArgument, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.wantCode, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/game/state", nil)
+
+			err := fmt.Errorf("operation failed: %w", tc.kind)
+			respondGameError(c, err)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tc.wantStatus)
+			}
+			if response := decodeErrorResponse(t, w); response.Code != tc.wantCode || response.Error != err.Error() {
+				t.Fatalf("response = %+v, want code %s with the error message", response, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestGameHandlerLogsRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	configureAuthenticatedRoutes(r.Group("/api/v1"), NewGameHandler(newFakeGameService(t)))
+
+	// 成功與失敗的處理日誌都帶有請求ID
+	for _, tc := range []struct {
+		requestID, state string
+		wantStatus       int
+	}{
+		{"req-ready", string(game.StateReady), http.StatusOK},
+		{"req-drawing", string(game.StateDrawing), http.StatusConflict},
+	} {
+		body, _ := json.Marshal(map[string]string{"state": tc.state})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/game/state", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.RequestIDHeader, tc.requestID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.wantStatus {
+			t.Fatalf("change state to %s: status = %d, body %s, want %d", tc.state, w.Code, w.Body.String(), tc.wantStatus)
+		}
+		if got := w.Header().Get(middleware.RequestIDHeader); got != tc.requestID {
+			t.Fatalf("%s header = %q, want %s", middleware.RequestIDHeader, got, tc.requestID)
+		}
+		if !strings.Contains(logs.String(), "["+tc.requestID+"]") {
+			t.Fatalf("handler logs %q do not mention request %s", logs.String(), tc.requestID)
+		}
+	}
+}
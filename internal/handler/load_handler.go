@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/config"
+	"g38_lottery_service/internal/service"
+	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadResponse 代表服務負載與容量資訊
+// @Description 服務負載與容量信息
+type LoadResponse struct {
+	// 進行中的遊戲數量
+	// @example 1
+	ActiveGames int `json:"activeGames"`
+
+	// 當前遊戲狀態
+	// @example DRAWING
+	GameState string `json:"gameState"`
+
+	// 荷官端與玩家端 WebSocket 連接總數
+	// @example 12
+	Connections int64 `json:"connections"`
+
+	// 玩家端 WebSocket 連接數
+	// @example 10
+	PlayerConnections int `json:"playerConnections"`
+
+	// 已認證的客戶端數量
+	// @example 2
+	AuthenticatedClients int `json:"authenticatedClients"`
+
+	// 被標記為重複的荷官連接數（同一遊戲有多個荷官在線）
+	// @example 0
+	DuplicateDealers int `json:"duplicateDealers"`
+
+	// 廣播隊列中等待處理的消息數
+	// @example 0
+	BroadcastQueueDepth int `json:"broadcastQueueDepth"`
+
+	// 廣播隊列容量
+	// @example 100
+	BroadcastQueueCapacity int `json:"broadcastQueueCapacity"`
+
+	// 因廣播隊列已滿而丟棄的消息數
+	// @example 0
+	DroppedBroadcasts int64 `json:"droppedBroadcasts"`
+
+	// 當前 goroutine 數量
+	// @example 42
+	Goroutines int `json:"goroutines"`
+
+	// 連接數上限
+	// @example 1000
+	MaxConnections int `json:"maxConnections"`
+
+	// 剩餘可承載的連接數
+	// @example 988
+	Capacity int `json:"capacity"`
+}
+
+// LoadHandler 處理服務負載查詢
+type LoadHandler struct {
+	cfg         *config.Config
+	gameService service.GameService
+	wsHandler   *dealerWebsocket.WebSocketHandler
+	wsManager   *dealerWebsocket.Manager
+
+	playerManager *websocket.Manager
+}
+
+// NewLoadHandler 創建一個新的負載處理器
+func NewLoadHandler(
+	cfg *config.Config,
+	gameService service.GameService,
+	wsHandler *dealerWebsocket.WebSocketHandler,
+	wsManager *dealerWebsocket.Manager,
+	playerManager *websocket.Manager,
+) *LoadHandler {
+	return &LoadHandler{
+		cfg:           cfg,
+		gameService:   gameService,
+		wsHandler:     wsHandler,
+		wsManager:     wsManager,
+		playerManager: playerManager,
+	}
+}
+
+// GetLoad 獲取服務負載
+// @Summary 獲取服務負載
+// @Description 返回進行中的遊戲、連接數、隊列深度與剩餘容量，供負載均衡與自動擴展使用
+// @Tags system
+// @Accept json
+// @Produce json
+// @Success 200 {object} LoadResponse "服務負載"
+// @Router /api/v1/system/load [get]
+func (h *LoadHandler) GetLoad(c *gin.Context) {
+	state := h.gameService.GetCurrentState()
+
+	// 待機與等待開局的遊戲尚未開始投注，不計入進行中
+	activeGames := 0
+	switch state {
+	case game.StateInitial, game.StateAgent, game.StateStandby, game.StateReady, game.StateCompleted:
+	default:
+		activeGames = 1
+	}
+
+	playerConnections := h.playerManager.GetClientCount()
+	connections := h.wsHandler.GetConnectionCount() + int64(playerConnections)
+	capacity := h.cfg.Server.MaxConns - int(connections)
+	if capacity < 0 {
+		capacity = 0
+	}
+
+	c.JSON(http.StatusOK, LoadResponse{
+		ActiveGames:            activeGames,
+		GameState:              string(state),
+		Connections:            connections,
+		PlayerConnections:      playerConnections,
+		AuthenticatedClients:   h.wsManager.GetAuthenticatedClientCount(),
+		DuplicateDealers:       h.wsManager.GetDuplicateDealerCount(),
+		BroadcastQueueDepth:    h.wsManager.GetBroadcastQueueDepth(),
+		BroadcastQueueCapacity: h.wsManager.GetBroadcastQueueCapacity(),
+		DroppedBroadcasts:      h.wsManager.GetDroppedBroadcastCount(),
+		Goroutines:             runtime.NumGoroutine(),
+		MaxConnections:         h.cfg.Server.MaxConns,
+		Capacity:               capacity,
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/config"
+	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadFixture 負載查詢測試使用的處理器與 WebSocket 測試服務器
+type loadFixture struct {
+	handler      *LoadHandler
+	dealerServer *httptest.Server
+	playerServer *httptest.Server
+}
+
+// newLoadFixture 創建負載處理器，荷官端與玩家端管理器都在運行
+func newLoadFixture(t *testing.T, maxConns int, states ...game.GameState) *loadFixture {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Server.MaxConns = maxConns
+
+	authFunc := func(token string) (uint, error) { return 0, errors.New("invalid token") }
+	dealerManager := dealerWebsocket.NewManager(authFunc)
+	wsHandler := dealerWebsocket.NewWebSocketHandler(dealerManager, authFunc)
+	playerManager := websocket.NewManager()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go dealerManager.Start(ctx)
+	go playerManager.Start(ctx)
+
+	fixture := &loadFixture{
+		handler:      NewLoadHandler(cfg, newFakeGameService(t, states...), wsHandler, dealerManager, playerManager),
+		dealerServer: httptest.NewServer(http.HandlerFunc(wsHandler.HandleWebSocket)),
+		playerServer: httptest.NewServer(http.HandlerFunc(playerManager.ServeWs)),
+	}
+	t.Cleanup(func() {
+		fixture.dealerServer.Close()
+		fixture.playerServer.Close()
+		cancel()
+	})
+	return fixture
+}
+
+// getLoad 查詢負載
+func (f *loadFixture) getLoad(t *testing.T) LoadResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/api/v1/system/load", f.handler.GetLoad)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/system/load", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s, want 200", w.Code, w.Body.String())
+	}
+
+	var response LoadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode load response: %v", err)
+	}
+	return response
+}
+
+func TestGetLoadCountsDealerAndPlayerConnections(t *testing.T) {
+	fixture := newLoadFixture(t, 10)
+	dialTestServer(t, fixture.dealerServer)
+	dialTestServer(t, fixture.playerServer)
+	dialTestServer(t, fixture.playerServer)
+
+	// 連接於背景註冊，等待計數穩定
+	var load LoadResponse
+	deadline := time.Now().Add(2 * time.Second)
+	for load = fixture.getLoad(t); load.Connections != 3; load = fixture.getLoad(t) {
+		if time.Now().After(deadline) {
+			t.Fatalf("connections = %d, want 1 dealer plus 2 players", load.Connections)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if load.PlayerConnections != 2 || load.Capacity != 7 {
+		t.Fatalf("player connections = %d, capacity = %d, want 2 and 7", load.PlayerConnections, load.Capacity)
+	}
+}
+
+func TestGetLoadActiveGames(t *testing.T) {
+	// 控制器從 AGENT 開始，經完整一局到達結算
+	resultStates := []game.GameState{
+		game.StateReady, game.StateBetting, game.StateDrawing,
+		game.StateExtraBet, game.StateExtraDraw, game.StateResult,
+	}
+	cases := []struct {
+		name   string
+		states []game.GameState
+		want   int
+	}{
+		{name: "agent", want: 0},
+		{name: "ready", states: []game.GameState{game.StateReady}, want: 0},
+		{name: "standby", states: append(resultStates, game.StateStandby), want: 0},
+		{name: "completed", states: append(resultStates, game.StateCompleted), want: 0},
+		{name: "betting", states: []game.GameState{game.StateReady, game.StateBetting}, want: 1},
+		{name: "drawing", states: []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing}, want: 1},
+		{name: "result", states: resultStates, want: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			load := newLoadFixture(t, 10, tc.states...).getLoad(t)
+			if load.ActiveGames != tc.want {
+				t.Fatalf("active games in %s = %d, want %d", load.GameState, load.ActiveGames, tc.want)
+			}
+		})
+	}
+}
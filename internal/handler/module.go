@@ -1,7 +1,15 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/config"
+	"g38_lottery_service/internal/service"
 	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/websocket"
 
 	"go.uber.org/fx"
 )
@@ -9,10 +17,55 @@ import (
 var Module = fx.Options(
 	fx.Provide(
 		NewGameHandler,
+		NewLoadHandler,
+		NewReadiness,
 		NewRouter,
 	),
 	fx.Invoke(func(handler *GameHandler, wsHandler *dealerWebsocket.WebSocketHandler) {
 		// 這裡不需要做任何事情，只是告訴 fx 我們需要這些依賴
 	}),
+	fx.Invoke(registerDealerMessageHandler),
+	// 將投注倒數廣播給所有荷官端，以及訂閱本局房間的玩家端
+	fx.Invoke(func(cfg *config.Config, manager *dealerWebsocket.Manager, playerManager *websocket.Manager, gameService service.GameService) error {
+		roomID := cfg.Game.RoomID
+		if err := playerManager.ValidateRoomID(roomID); err != nil {
+			return fmt.Errorf("invalid game room id: %w", err)
+		}
+
+		gameService.SetCountdownListener(func(countdown game.Countdown) {
+			message := dealerWebsocket.NewMessage(dealerWebsocket.MessageTypeCountdown, countdown)
+			if err := manager.BroadcastToAll(message); err != nil {
+				log.Printf("廣播遊戲 %s 倒數失敗: %v", countdown.GameID, err)
+			}
+
+			data, err := json.Marshal(message)
+			if err != nil {
+				log.Printf("序列化遊戲 %s 倒數失敗: %v", countdown.GameID, err)
+				return
+			}
+			if err := playerManager.BroadcastToRoom(roomID, data); err != nil {
+				log.Printf("廣播遊戲 %s 倒數到房間 %s 失敗: %v", countdown.GameID, roomID, err)
+			}
+		})
+		return nil
+	}),
 	fx.Invoke(StartServer),
 )
+
+// registerDealerMessageHandler 將荷官端業務消息交由遊戲服務處理，遊戲錯誤通知同時轉發給訂閱本局房間的玩家端
+func registerDealerMessageHandler(cfg *config.Config, manager *dealerWebsocket.Manager, playerManager *websocket.Manager, gameService service.GameService) {
+	roomID := cfg.Game.RoomID
+
+	messageHandler := dealerWebsocket.NewDealerMessageHandler(gameService)
+	messageHandler.SetGameErrorListener(func(message *dealerWebsocket.BasicMessage) {
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Printf("序列化遊戲錯誤通知失敗: %v", err)
+			return
+		}
+		if err := playerManager.BroadcastToRoom(roomID, data); err != nil {
+			log.Printf("廣播遊戲錯誤通知到房間 %s 失敗: %v", roomID, err)
+		}
+	})
+	manager.SetMessageHandler(messageHandler)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/config"
+	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/websocket"
+
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// readMessageData 讀取消息直到收到指定類型，返回其數據；玩家端的歡迎消息不是 JSON，略過
+func readMessageData(t *testing.T, conn *gorillaws.Conn, messageType string) json.RawMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %s: %v", messageType, err)
+		}
+		var message struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if json.Unmarshal(data, &message) == nil && message.Type == messageType {
+			return message.Data
+		}
+	}
+}
+
+// dialTestServer 連接測試服務器
+func dialTestServer(t *testing.T, server *httptest.Server) *gorillaws.Conn {
+	t.Helper()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestDealerGameErrorBroadcastToPlayerRoom(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Game.RoomID = "SG01"
+
+	gameService := newFakeGameService(t, game.StateReady, game.StateBetting, game.StateDrawing,
+		game.StateJPStandby, game.StateJPBetting, game.StateJPDrawing)
+	for i := 0; i < 30; i++ {
+		if _, err := gameService.controller.DrawJPBall(); err != nil {
+			t.Fatalf("DrawJPBall %d: %v", i+1, err)
+		}
+	}
+
+	authFunc := func(token string) (uint, error) {
+		if token != "dealer-1" {
+			return 0, errors.New("invalid token")
+		}
+		return 1, nil
+	}
+	dealerManager := dealerWebsocket.NewManager(authFunc)
+	playerManager := websocket.NewManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	go dealerManager.Start(ctx)
+	go playerManager.Start(ctx)
+	t.Cleanup(cancel)
+
+	registerDealerMessageHandler(cfg, dealerManager, playerManager, gameService)
+
+	dealerServer := httptest.NewServer(http.HandlerFunc(dealerWebsocket.NewWebSocketHandler(dealerManager, authFunc).HandleWebSocket))
+	playerServer := httptest.NewServer(http.HandlerFunc(playerManager.ServeWs))
+	t.Cleanup(dealerServer.Close)
+	t.Cleanup(playerServer.Close)
+
+	// 玩家端訂閱本局房間
+	player := dialTestServer(t, playerServer)
+	if err := player.WriteJSON(websocket.RoomCommand{Type: websocket.MessageTypeSubscribeRoom, RoomID: cfg.Game.RoomID}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	readMessageData(t, player, websocket.MessageTypeRoomSubscribed)
+
+	// 荷官端認證後請求抽取JP球，JP抽球數已達上限
+	dealer := dialTestServer(t, dealerServer)
+	if err := dealer.WriteJSON(map[string]interface{}{
+		"type": dealerWebsocket.MessageTypeAuthentication,
+		"data": dealerWebsocket.AuthMessage{Token: "dealer-1"},
+	}); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	readMessageData(t, dealer, dealerWebsocket.MessageTypeAuthSuccess)
+	if err := dealer.WriteJSON(map[string]string{"type": dealerWebsocket.MessageTypeDrawJPBall}); err != nil {
+		t.Fatalf("draw JP ball: %v", err)
+	}
+
+	var notification dealerWebsocket.GameErrorMessage
+	if err := json.Unmarshal(readMessageData(t, player, dealerWebsocket.MessageTypeGameError), &notification); err != nil {
+		t.Fatalf("decode %s: %v", dealerWebsocket.MessageTypeGameError, err)
+	}
+	if notification.Operation != dealerWebsocket.MessageTypeDrawJPBall || notification.Code != "BALLS_EXHAUSTED" {
+		t.Fatalf("player room game error = %+v, want DRAW_JP_BALL BALLS_EXHAUSTED", notification)
+	}
+}
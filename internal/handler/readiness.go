@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Readiness 記錄服務是否可接收流量，關閉時先標記為未就緒讓負載均衡器停止轉發
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness 創建就緒狀態，初始為未就緒，待服務器啟動後才標記為就緒
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady 設置是否就緒
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady 返回是否就緒
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load()
+}
+
+// ServeHTTP 就緒檢查端點，未就緒時返回 503
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !r.IsReady() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status": "not_ready"}`))
+		return
+	}
+	w.Write([]byte(`{"status": "ready"}`))
+}
@@ -1,16 +1,24 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"g38_lottery_service/internal/config"
 	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/middleware"
+	"g38_lottery_service/pkg/websocket"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/fx"
 )
 
 type SuccessResponse struct {
@@ -19,42 +27,90 @@ type SuccessResponse struct {
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code,omitempty"` // 機器可讀的錯誤代碼，例如 INVALID_STATE
 }
 
 func NewRouter(
 	cfg *config.Config,
 	gameHandler *GameHandler,
+	loadHandler *LoadHandler,
+	readiness *Readiness,
 	wsHandler *dealerWebsocket.WebSocketHandler,
+	playerManager *websocket.Manager,
 ) *gin.Engine {
-	r := gin.Default()
-	r.Use(configureCORS())
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Logger(), middleware.Recovery())
+	r.Use(configureCORS(cfg.CORS))
 	r.GET("/api-docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, SuccessResponse{Message: "Service is healthy"})
 	})
 
+	// 就緒檢查，關閉期間返回 503
+	r.GET("/ready", gin.WrapH(readiness))
+
+	// 荷官端 WebSocket，既有荷官客戶端透過 API 端口的 /ws 連接
 	r.GET("/ws", func(c *gin.Context) {
 		wsHandler.HandleWebSocket(c.Writer, c.Request)
 	})
 
+	// 玩家端 WebSocket，支援房間訂閱
+	r.GET("/player/ws", func(c *gin.Context) {
+		playerManager.ServeWs(c.Writer, c.Request)
+	})
+
+	// 共用端口模式或未設定荷官端獨立端口時，荷官端 WebSocket 掛在 API 端口上，以路徑區分
+	if cfg.Server.SharedPort || cfg.Server.DealerWSPort == 0 {
+		r.GET("/dealer/ws", func(c *gin.Context) {
+			wsHandler.HandleWebSocket(c.Writer, c.Request)
+		})
+	}
+
 	api := r.Group("/api/v1")
 	{
-		configurePublicRoutes(api, gameHandler)
+		configurePublicRoutes(api, gameHandler, loadHandler)
 		configureAuthenticatedRoutes(api, gameHandler)
 	}
 
 	return r
 }
 
-func configureCORS() gin.HandlerFunc {
+// configureCORS 依設定的來源白名單處理跨來源請求，不在白名單內的預檢請求返回 403
+func configureCORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowAll := false
+	allowedOrigins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowedOrigins[origin] = true
+	}
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		origin := c.GetHeader("Origin")
+		allowed := allowAll || allowedOrigins[origin]
+
+		if allowed {
+			// 瀏覽器不接受萬用來源搭配憑證，只有回應特定來源時才允許攜帶憑證
+			if allowAll {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+				c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+				c.Writer.Header().Add("Vary", "Origin")
+			}
+			c.Writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			c.Writer.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+		}
 
 		if c.Request.Method == "OPTIONS" {
+			if origin != "" && !allowed {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
 			c.AbortWithStatus(204)
 			return
 		}
@@ -63,32 +119,62 @@ func configureCORS() gin.HandlerFunc {
 	}
 }
 
-func configurePublicRoutes(api *gin.RouterGroup, gameHandler *GameHandler) {
+func configurePublicRoutes(api *gin.RouterGroup, gameHandler *GameHandler, loadHandler *LoadHandler) {
 	api.GET("/game/status", gameHandler.GetGameStatus)
 	api.GET("/game/state", gameHandler.GetGameState)
+	api.GET("/system/load", loadHandler.GetLoad)
 }
 
 func configureAuthenticatedRoutes(api *gin.RouterGroup, gameHandler *GameHandler) {
 	authorized := api.Group("/")
 
 	authorized.POST("/game/state", gameHandler.ChangeGameState)
+	authorized.POST("/game/undo-ball", gameHandler.UndoLastBall)
+	authorized.POST("/game/lucky-numbers", gameHandler.SetLuckyNumbers)
 }
 
-func StartServer(cfg *config.Config, router *gin.Engine, wsHandler *dealerWebsocket.WebSocketHandler) {
-	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-	fmt.Printf("正在使用端口 %d 啟動 API 服務器...\n", cfg.Server.Port)
+func StartServer(lc fx.Lifecycle, cfg *config.Config, router *gin.Engine, readiness *Readiness, wsHandler *dealerWebsocket.WebSocketHandler, playerManager *websocket.Manager) {
+	servers := buildServers(cfg, router, readiness, wsHandler, playerManager)
 
-	// 將 Gin 服務器的啟動放在單獨的 goroutine 中，避免阻塞 FX 生命週期
-	go func() {
-		if err := router.Run(addr); err != nil {
-			log.Fatalf("無法啟動 API 服務器: %v", err)
-		}
-	}()
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			listeners, err := listenServers(servers)
+			if err != nil {
+				return err
+			}
+
+			// 將服務器的運行放在單獨的 goroutine 中，避免阻塞 FX 生命週期
+			for i, server := range servers {
+				go func(server *http.Server, listener net.Listener) {
+					if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						log.Fatalf("服務器 %s 運行失敗: %v", server.Addr, err)
+					}
+				}(server, listeners[i])
+			}
+
+			readiness.SetReady(true)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			shutdownServers(ctx, cfg.Shutdown, readiness, servers)
+			return nil
+		},
+	})
+}
+
+// buildServers 依端口設定建立 API 服務器，以及非共用端口模式下的荷官端與玩家端 WebSocket 服務器
+func buildServers(cfg *config.Config, router *gin.Engine, readiness *Readiness, wsHandler *dealerWebsocket.WebSocketHandler, playerManager *websocket.Manager) []*http.Server {
+	servers := []*http.Server{
+		{Addr: fmt.Sprintf(":%d", cfg.Server.Port), Handler: router},
+	}
+	fmt.Printf("正在使用端口 %d 啟動 API 服務器...\n", cfg.Server.Port)
 
-	// 啟動專門的荷官 WebSocket 服務器
-	if cfg.Server.DealerWSPort > 0 {
-		go func() {
-			dealerWSAddr := fmt.Sprintf(":%d", cfg.Server.DealerWSPort)
+	// 共用端口模式下，荷官端 (/ws、/dealer/ws) 與玩家端 (/player/ws) 都由 API 服務器處理
+	if cfg.Server.SharedPort {
+		fmt.Printf("已啟用共用端口模式，荷官端與玩家端 WebSocket 使用端口 %d\n", cfg.Server.Port)
+	} else {
+		// 專門的荷官 WebSocket 服務器
+		if cfg.Server.DealerWSPort > 0 {
 			dealerMux := http.NewServeMux()
 
 			// 健康檢查端點
@@ -96,22 +182,17 @@ func StartServer(cfg *config.Config, router *gin.Engine, wsHandler *dealerWebsoc
 				w.Header().Set("Content-Type", "application/json")
 				w.Write([]byte(`{"status": "ok", "service": "dealer_websocket"}`))
 			})
+			dealerMux.Handle("/ready", readiness)
 
 			// 註冊 WebSocket 處理程序
 			dealerMux.HandleFunc("/dealer/ws", wsHandler.HandleWebSocket)
 
 			fmt.Printf("正在使用端口 %d 啟動荷官 WebSocket 服務器...\n", cfg.Server.DealerWSPort)
+			servers = append(servers, &http.Server{Addr: fmt.Sprintf(":%d", cfg.Server.DealerWSPort), Handler: dealerMux})
+		}
 
-			if err := http.ListenAndServe(dealerWSAddr, dealerMux); err != nil {
-				log.Fatalf("無法啟動荷官 WebSocket 服務器: %v", err)
-			}
-		}()
-	}
-
-	// 啟動專門的玩家 WebSocket 服務器
-	if cfg.Server.PlayerWSPort > 0 {
-		go func() {
-			playerWSAddr := fmt.Sprintf(":%d", cfg.Server.PlayerWSPort)
+		// 專門的玩家 WebSocket 服務器
+		if cfg.Server.PlayerWSPort > 0 {
 			playerMux := http.NewServeMux()
 
 			// 健康檢查端點
@@ -119,15 +200,65 @@ func StartServer(cfg *config.Config, router *gin.Engine, wsHandler *dealerWebsoc
 				w.Header().Set("Content-Type", "application/json")
 				w.Write([]byte(`{"status": "ok", "service": "player_websocket"}`))
 			})
+			playerMux.Handle("/ready", readiness)
 
-			// 註冊 WebSocket 處理程序
-			playerMux.HandleFunc("/ws", wsHandler.HandleWebSocket) // 使用相同的處理程序，但路徑不同
+			// 註冊 WebSocket 處理程序，玩家端口上的 /ws 與 API 端口相同的 /player/ws 都轉交玩家端管理器
+			playerMux.HandleFunc("/ws", playerManager.ServeWs)
+			playerMux.HandleFunc("/player/ws", playerManager.ServeWs)
 
 			fmt.Printf("正在使用端口 %d 啟動玩家 WebSocket 服務器...\n", cfg.Server.PlayerWSPort)
+			servers = append(servers, &http.Server{Addr: fmt.Sprintf(":%d", cfg.Server.PlayerWSPort), Handler: playerMux})
+		}
+	}
+
+	return servers
+}
 
-			if err := http.ListenAndServe(playerWSAddr, playerMux); err != nil {
-				log.Fatalf("無法啟動玩家 WebSocket 服務器: %v", err)
+// listenServers 依序綁定所有服務器的端口，任一端口綁定失敗時關閉已綁定的端口並返回錯誤
+func listenServers(servers []*http.Server) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, server := range servers {
+		listener, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
 			}
-		}()
+			return nil, fmt.Errorf("無法啟動服務器 %s: %w", server.Addr, err)
+		}
+		log.Printf("服務器已綁定地址 %s", listener.Addr())
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// shutdownServers 標記為未就緒並等待寬限時間後，在排空時限內關閉所有服務器
+func shutdownServers(ctx context.Context, shutdown config.ShutdownConfig, readiness *Readiness, servers []*http.Server) {
+	// 先標記為未就緒，並等待負載均衡器察覺後停止轉發新流量
+	readiness.SetReady(false)
+	waitReadinessGrace(ctx, shutdown.ReadinessGrace)
+
+	// 在排空時限內等待進行中的請求完成
+	drainCtx, cancel := context.WithTimeout(ctx, shutdown.DrainTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(drainCtx); err != nil {
+			log.Printf("關閉服務器 %s 時發生錯誤: %v", server.Addr, err)
+		}
+	}
+}
+
+// waitReadinessGrace 等待就緒檢查的寬限時間，關閉時限先到則提前返回
+func waitReadinessGrace(ctx context.Context, grace time.Duration) {
+	if grace <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
 	}
 }
@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"g38_lottery_service/internal/config"
+	"g38_lottery_service/pkg/dealerWebsocket"
+	"g38_lottery_service/pkg/websocket"
+
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// startTestServer 在隨機端口啟動帶有 /ready 與 /ping 的服務器
+func startTestServer(t *testing.T, readiness *Readiness) (*http.Server, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/ready", readiness)
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestShutdownServersWaitsForReadinessGrace(t *testing.T) {
+	readiness := NewReadiness()
+	readiness.SetReady(true)
+	server, baseURL := startTestServer(t, readiness)
+
+	const grace = 200 * time.Millisecond
+	shutdown := config.ShutdownConfig{ReadinessGrace: grace, DrainTimeout: time.Second}
+
+	done := make(chan struct{})
+	started := time.Now()
+	go func() {
+		shutdownServers(context.Background(), shutdown, readiness, []*http.Server{server})
+		close(done)
+	}()
+
+	// 寬限期間就緒檢查返回 503，但服務器仍然處理請求
+	deadline := time.Now().Add(time.Second)
+	for readiness.IsReady() {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness still true after shutdown started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if status := getStatus(t, baseURL+"/ready"); status != http.StatusServiceUnavailable {
+		t.Fatalf("/ready status during grace = %d, want 503", status)
+	}
+	if status := getStatus(t, baseURL+"/ping"); status != http.StatusOK {
+		t.Fatalf("/ping status during grace = %d, want 200", status)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdownServers did not return")
+	}
+	if elapsed := time.Since(started); elapsed < grace {
+		t.Fatalf("shutdown finished after %v, want at least the %v grace", elapsed, grace)
+	}
+	if _, err := http.Get(baseURL + "/ping"); err == nil {
+		t.Fatal("server still serving after shutdown")
+	}
+}
+
+func TestShutdownServersGraceBoundedByContext(t *testing.T) {
+	readiness := NewReadiness()
+	readiness.SetReady(true)
+	server, _ := startTestServer(t, readiness)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	shutdown := config.ShutdownConfig{ReadinessGrace: time.Hour, DrainTimeout: time.Second}
+	started := time.Now()
+	shutdownServers(ctx, shutdown, readiness, []*http.Server{server})
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("shutdown took %v, want it bounded by the stop context", elapsed)
+	}
+}
+
+func TestListenServersClosesOpenedListenersOnFailure(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer occupied.Close()
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	freeAddr := free.Addr().String()
+	free.Close()
+
+	servers := []*http.Server{{Addr: freeAddr}, {Addr: occupied.Addr().String()}}
+	if _, err := listenServers(servers); err == nil {
+		t.Fatal("listenServers succeeded with an occupied port")
+	}
+
+	// 失敗時已綁定的端口應被釋放
+	reopened, err := net.Listen("tcp", freeAddr)
+	if err != nil {
+		t.Fatalf("port %s still bound after failed start: %v", freeAddr, err)
+	}
+	reopened.Close()
+}
+
+func TestListenServersBindsAllServers(t *testing.T) {
+	servers := []*http.Server{{Addr: "127.0.0.1:0"}, {Addr: "127.0.0.1:0"}}
+	listeners, err := listenServers(servers)
+	if err != nil {
+		t.Fatalf("listenServers: %v", err)
+	}
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+
+	if len(listeners) != len(servers) {
+		t.Fatalf("got %d listeners, want %d", len(listeners), len(servers))
+	}
+}
+
+// corsRequest 經過 CORS 中介層發送請求並返回回應
+func corsRequest(cors config.CORSConfig, method, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(configureCORS(cors))
+	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	req := httptest.NewRequest(method, "/ping", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSWildcardOmitsCredentials(t *testing.T) {
+	cors := config.CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}
+	w := corsRequest(cors, http.MethodGet, "https://player.example.com")
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Allow-Credentials = %q with wildcard origin, want none", got)
+	}
+}
+
+func TestCORSSpecificOriginAllowsCredentials(t *testing.T) {
+	const origin = "https://dealer.example.com"
+	cors := config.CORSConfig{AllowedOrigins: []string{origin}, AllowedMethods: []string{"GET"}}
+	w := corsRequest(cors, http.MethodGet, origin)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+		t.Fatalf("Allow-Origin = %q, want %q", got, origin)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Allow-Credentials = %q, want true", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORSRejectsDisallowedOrigin(t *testing.T) {
+	cors := config.CORSConfig{AllowedOrigins: []string{"https://dealer.example.com"}, AllowedMethods: []string{"GET"}}
+
+	w := corsRequest(cors, http.MethodOptions, "https://evil.example.com")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("preflight status = %d, want 403", w.Code)
+	}
+
+	w = corsRequest(cors, http.MethodGet, "https://evil.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("Allow-Origin = %q for disallowed origin, want none", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Allow-Credentials = %q for disallowed origin, want none", got)
+	}
+}
+
+// 連接端點所屬的 WebSocket 服務
+const (
+	endpointDealer = "dealer"
+	endpointPlayer = "player"
+)
+
+// buildTestServers 以 NewRouter 組裝的路由與 buildServers 建立服務器，荷官端與玩家端管理器都在運行
+func buildTestServers(t *testing.T, cfg *config.Config) []*http.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	authFunc := func(token string) (uint, error) { return 0, errors.New("invalid token") }
+	dealerManager := dealerWebsocket.NewManager(authFunc)
+	wsHandler := dealerWebsocket.NewWebSocketHandler(dealerManager, authFunc)
+	playerManager := websocket.NewManager()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go dealerManager.Start(ctx)
+	go playerManager.Start(ctx)
+	t.Cleanup(cancel)
+
+	readiness := NewReadiness()
+	router := NewRouter(cfg, NewGameHandler(newFakeGameService(t)), nil, readiness, wsHandler, playerManager)
+	return buildServers(cfg, router, readiness, wsHandler, playerManager)
+}
+
+// serveTestServer 以 httptest 啟動服務器的處理器
+func serveTestServer(t *testing.T, server *http.Server) *httptest.Server {
+	t.Helper()
+
+	test := httptest.NewServer(server.Handler)
+	t.Cleanup(test.Close)
+	return test
+}
+
+// startRouterServer 以 NewRouter 組裝的路由啟動測試服務器，荷官端與玩家端管理器都在運行
+func startRouterServer(t *testing.T, cfg *config.Config) *httptest.Server {
+	t.Helper()
+
+	return serveTestServer(t, buildTestServers(t, cfg)[0])
+}
+
+// identifyEndpoint 連接指定路徑並發送一條業務消息，依回應判斷由荷官端或玩家端處理
+func identifyEndpoint(t *testing.T, server *httptest.Server, path string) string {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + path
+	conn, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", path, err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(gorillaws.TextMessage, []byte(`{"type":"GET_STATUS"}`)); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+
+	// 荷官端要求先認證，玩家端連接後即發送歡迎消息
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		switch {
+		case strings.Contains(string(message), "authentication required"):
+			return endpointDealer
+		case strings.Contains(string(message), "Welcome to WebSocket Server"):
+			return endpointPlayer
+		}
+	}
+}
+
+func TestRouterWebSocketPaths(t *testing.T) {
+	server := startRouterServer(t, &config.Config{})
+
+	// 既有荷官客戶端使用的 /ws 維持由荷官端處理，玩家端使用 /player/ws
+	cases := map[string]string{
+		"/ws":        endpointDealer,
+		"/dealer/ws": endpointDealer,
+		"/player/ws": endpointPlayer,
+	}
+	for path, want := range cases {
+		if got := identifyEndpoint(t, server, path); got != want {
+			t.Errorf("%s served by %s endpoint, want %s", path, got, want)
+		}
+	}
+}
+
+func TestSharedPortServesAPIAndWebSockets(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.SharedPort = true
+	cfg.Server.DealerWSPort = 8081
+	cfg.Server.PlayerWSPort = 8082
+
+	// 共用端口模式只建立 API 服務器，忽略荷官端與玩家端的獨立端口
+	servers := buildTestServers(t, cfg)
+	if len(servers) != 1 {
+		t.Fatalf("built %d servers in shared port mode, want only the API server", len(servers))
+	}
+	server := serveTestServer(t, servers[0])
+
+	if status := getStatus(t, server.URL+"/api/v1/game/state"); status != http.StatusOK {
+		t.Fatalf("API status on the shared port = %d, want 200", status)
+	}
+	for path, want := range map[string]string{"/dealer/ws": endpointDealer, "/player/ws": endpointPlayer} {
+		if got := identifyEndpoint(t, server, path); got != want {
+			t.Errorf("%s on the shared port served by %s endpoint, want %s", path, got, want)
+		}
+	}
+}
+
+func TestSeparatePortsServeDealerWebSocketOnDealerPort(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.DealerWSPort = 8081
+	cfg.Server.PlayerWSPort = 8082
+
+	servers := buildTestServers(t, cfg)
+	if len(servers) != 3 {
+		t.Fatalf("built %d servers with separate ports, want API, dealer and player servers", len(servers))
+	}
+
+	// 荷官端有獨立端口時，API 端口不掛載 /dealer/ws
+	api := serveTestServer(t, servers[0])
+	if status := getStatus(t, api.URL+"/dealer/ws"); status != http.StatusNotFound {
+		t.Fatalf("/dealer/ws status on the API port = %d, want 404", status)
+	}
+	if got := identifyEndpoint(t, serveTestServer(t, servers[1]), "/dealer/ws"); got != endpointDealer {
+		t.Fatalf("/dealer/ws on the dealer port served by %s endpoint, want %s", got, endpointDealer)
+	}
+	if got := identifyEndpoint(t, serveTestServer(t, servers[2]), "/ws"); got != endpointPlayer {
+		t.Fatalf("/ws on the player port served by %s endpoint, want %s", got, endpointPlayer)
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Game 遊戲記錄模型
+type Game struct {
+	ID             string          `gorm:"size:36;primarykey" json:"id"`
+	State          string          `gorm:"size:20;not null;index" json:"state"`
+	StartTime      time.Time       `json:"start_time"`
+	EndTime        *time.Time      `json:"end_time,omitempty"`
+	HasJackpot     bool            `json:"has_jackpot"`
+	ExtraBallCount int             `json:"extra_ball_count"`
+	StateStartTime *time.Time      `json:"state_start_time,omitempty"`
+	MaxTimeout     int             `json:"max_timeout"` // 當前狀態最大超時時間（秒）
+	LuckyNumbers   json.RawMessage `gorm:"column:lucky_numbers_json" json:"lucky_numbers,omitempty"`
+	DrawnBalls     json.RawMessage `gorm:"column:drawn_balls_json" json:"drawn_balls,omitempty"`
+	ExtraBalls     json.RawMessage `gorm:"column:extra_balls_json" json:"extra_balls,omitempty"`
+	JackpotInfo    json.RawMessage `gorm:"column:jackpot_info_json" json:"jackpot_info,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// TableName 指定資料表名稱
+func (Game) TableName() string {
+	return "games"
+}
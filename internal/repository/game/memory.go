@@ -0,0 +1,64 @@
+package game
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"g38_lottery_service/internal/model"
+)
+
+// memoryRepository 以記憶體保存遊戲記錄的 Repository 實現，供測試與無資料庫環境使用
+type memoryRepository struct {
+	mu    sync.RWMutex
+	games map[string]model.Game
+}
+
+// NewMemoryRepository 創建記憶體遊戲記錄倉庫實例
+func NewMemoryRepository() Repository {
+	return &memoryRepository{games: make(map[string]model.Game)}
+}
+
+// SaveGame 新增或更新遊戲記錄，保存副本以免調用者之後修改影響倉庫內容
+func (r *memoryRepository) SaveGame(ctx context.Context, game *model.Game) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.games[game.ID] = *game
+	return nil
+}
+
+// GetGameByID 根據遊戲ID獲取遊戲記錄
+func (r *memoryRepository) GetGameByID(ctx context.Context, gameID string) (*model.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	game, ok := r.games[gameID]
+	if !ok {
+		return nil, nil // 遊戲不存在但不是錯誤
+	}
+	return &game, nil
+}
+
+// ListGamesByStatus 獲取指定狀態的遊戲記錄，依開始時間由新到舊排序，limit 小於等於 0 表示不限制
+func (r *memoryRepository) ListGamesByStatus(ctx context.Context, status string, limit int) ([]*model.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	games := make([]*model.Game, 0)
+	for _, game := range r.games {
+		if game.State == status {
+			game := game
+			games = append(games, &game)
+		}
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].StartTime.After(games[j].StartTime)
+	})
+	if limit > 0 && len(games) > limit {
+		games = games[:limit]
+	}
+
+	return games, nil
+}
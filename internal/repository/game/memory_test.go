@@ -0,0 +1,103 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g38_lottery_service/internal/model"
+)
+
+// saveGames 將遊戲記錄寫入倉庫
+func saveGames(t *testing.T, repo Repository, games ...model.Game) {
+	t.Helper()
+
+	for i := range games {
+		if err := repo.SaveGame(context.Background(), &games[i]); err != nil {
+			t.Fatalf("SaveGame(%s): %v", games[i].ID, err)
+		}
+	}
+}
+
+// gameIDs 返回遊戲記錄的ID，保持原順序
+func gameIDs(games []*model.Game) []string {
+	ids := make([]string, 0, len(games))
+	for _, game := range games {
+		ids = append(ids, game.ID)
+	}
+	return ids
+}
+
+func TestMemoryRepositoryGetGameByID(t *testing.T) {
+	repo := NewMemoryRepository()
+	saveGames(t, repo, model.Game{ID: "G1", State: "RESULT"})
+
+	game, err := repo.GetGameByID(context.Background(), "G1")
+	if err != nil || game == nil || game.State != "RESULT" {
+		t.Fatalf("GetGameByID(G1) = %+v, %v, want the saved RESULT game", game, err)
+	}
+
+	// 返回副本，修改不影響倉庫內容
+	game.State = "COMPLETED"
+	if again, _ := repo.GetGameByID(context.Background(), "G1"); again.State != "RESULT" {
+		t.Fatalf("stored state = %s after modifying the returned game, want RESULT", again.State)
+	}
+
+	// 更新同一ID的記錄
+	saveGames(t, repo, model.Game{ID: "G1", State: "COMPLETED"})
+	if updated, _ := repo.GetGameByID(context.Background(), "G1"); updated.State != "COMPLETED" {
+		t.Fatalf("state after update = %s, want COMPLETED", updated.State)
+	}
+}
+
+func TestMemoryRepositoryGetGameByIDNotFound(t *testing.T) {
+	repo := NewMemoryRepository()
+
+	game, err := repo.GetGameByID(context.Background(), "missing")
+	if game != nil || err != nil {
+		t.Fatalf("GetGameByID(missing) = %+v, %v, want nil, nil", game, err)
+	}
+}
+
+func TestMemoryRepositoryListGamesByStatus(t *testing.T) {
+	repo := NewMemoryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	saveGames(t, repo,
+		model.Game{ID: "G1", State: "COMPLETED", StartTime: base},
+		model.Game{ID: "G2", State: "DRAWING", StartTime: base.Add(time.Minute)},
+		model.Game{ID: "G3", State: "COMPLETED", StartTime: base.Add(2 * time.Minute)},
+		model.Game{ID: "G4", State: "COMPLETED", StartTime: base.Add(time.Second)},
+	)
+
+	cases := []struct {
+		name   string
+		status string
+		limit  int
+		want   []string
+	}{
+		{name: "newest first", status: "COMPLETED", limit: 0, want: []string{"G3", "G4", "G1"}},
+		{name: "negative limit is unlimited", status: "COMPLETED", limit: -1, want: []string{"G3", "G4", "G1"}},
+		{name: "limit", status: "COMPLETED", limit: 2, want: []string{"G3", "G4"}},
+		{name: "limit above count", status: "DRAWING", limit: 10, want: []string{"G2"}},
+		{name: "no match", status: "BETTING", limit: 0, want: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			games, err := repo.ListGamesByStatus(context.Background(), tc.status, tc.limit)
+			if err != nil {
+				t.Fatalf("ListGamesByStatus: %v", err)
+			}
+
+			ids := gameIDs(games)
+			if len(ids) != len(tc.want) {
+				t.Fatalf("ListGamesByStatus(%s, %d) = %v, want %v", tc.status, tc.limit, ids, tc.want)
+			}
+			for i := range ids {
+				if ids[i] != tc.want[i] {
+					t.Fatalf("ListGamesByStatus(%s, %d) = %v, want %v", tc.status, tc.limit, ids, tc.want)
+				}
+			}
+		})
+	}
+}
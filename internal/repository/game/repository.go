@@ -0,0 +1,58 @@
+package game
+
+import (
+	"context"
+	"errors"
+
+	"g38_lottery_service/internal/model"
+	"gorm.io/gorm"
+)
+
+// Repository 遊戲記錄倉庫接口
+type Repository interface {
+	SaveGame(ctx context.Context, game *model.Game) error
+	GetGameByID(ctx context.Context, gameID string) (*model.Game, error)
+	ListGamesByStatus(ctx context.Context, status string, limit int) ([]*model.Game, error)
+}
+
+// repository 使用 GORM 實現 Repository 接口
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 創建遊戲記錄倉庫實例
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// SaveGame 新增或更新遊戲記錄
+func (r *repository) SaveGame(ctx context.Context, game *model.Game) error {
+	return r.db.WithContext(ctx).Save(game).Error
+}
+
+// GetGameByID 根據遊戲ID獲取遊戲記錄
+func (r *repository) GetGameByID(ctx context.Context, gameID string) (*model.Game, error) {
+	var game model.Game
+	if err := r.db.WithContext(ctx).Where("id = ?", gameID).First(&game).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 遊戲不存在但不是錯誤
+		}
+		return nil, err
+	}
+	return &game, nil
+}
+
+// ListGamesByStatus 獲取指定狀態的遊戲記錄，依開始時間由新到舊排序，limit 小於等於 0 表示不限制
+func (r *repository) ListGamesByStatus(ctx context.Context, status string, limit int) ([]*model.Game, error) {
+	var games []*model.Game
+
+	query := r.db.WithContext(ctx).Where("state = ?", status).Order("start_time DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&games).Error; err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// capturedQuery 記錄 DryRun 模式產生的查詢語句與參數
+type capturedQuery struct {
+	SQL  string
+	Vars string
+}
+
+// newDryRunDB 創建只產生 SQL 不連接資料庫的 GORM 實例，並記錄最後一條查詢語句
+func newDryRunDB(t *testing.T) (*gorm.DB, *capturedQuery) {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	query := &capturedQuery{}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		query.SQL = tx.Statement.SQL.String()
+		query.Vars = fmt.Sprint(tx.Statement.Vars)
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db, query
+}
+
+func TestRepositoryGetGameByIDQuery(t *testing.T) {
+	db, query := newDryRunDB(t)
+
+	NewRepository(db).GetGameByID(context.Background(), "G1")
+
+	want := "SELECT * FROM `games` WHERE id = ? ORDER BY `games`.`id` LIMIT ?"
+	if query.SQL != want || query.Vars != "[G1 1]" {
+		t.Fatalf("query = %q %s, want %q [G1 1]", query.SQL, query.Vars, want)
+	}
+}
+
+func TestRepositoryListGamesByStatusQuery(t *testing.T) {
+	cases := []struct {
+		limit    int
+		wantSQL  string
+		wantVars string
+	}{
+		{limit: 5, wantSQL: "SELECT * FROM `games` WHERE state = ? ORDER BY start_time DESC LIMIT ?", wantVars: "[COMPLETED 5]"},
+		{limit: 0, wantSQL: "SELECT * FROM `games` WHERE state = ? ORDER BY start_time DESC", wantVars: "[COMPLETED]"},
+	}
+
+	for _, tc := range cases {
+		db, query := newDryRunDB(t)
+
+		NewRepository(db).ListGamesByStatus(context.Background(), "COMPLETED", tc.limit)
+
+		if query.SQL != tc.wantSQL || query.Vars != tc.wantVars {
+			t.Errorf("limit %d: query = %q %s, want %q %s", tc.limit, query.SQL, query.Vars, tc.wantSQL, tc.wantVars)
+		}
+	}
+}
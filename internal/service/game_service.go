@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"g38_lottery_service/game"
+	"g38_lottery_service/internal/config"
+	redis "g38_lottery_service/pkg/redisManager"
 
 	"go.uber.org/fx"
 )
@@ -18,18 +20,38 @@ type GameService interface {
 	GetCurrentState() game.GameState
 	// 更改遊戲狀態
 	ChangeState(state game.GameState) error
+	// 更改遊戲狀態並返回轉換後的遊戲狀態
+	ChangeStateAndGetStatus(state game.GameState) (*game.GameStatusResponse, error)
 	// 設置JP觸發號碼
 	SetJPTriggerNumbers(numbers []int) error
+	// 隨機產生幸運號碼
+	GenerateLuckyNumbers() ([]int, error)
+	// 獲取幸運號碼
+	GetLuckyNumbers() []int
 	// 驗證兩顆球的有效性
 	VerifyTwoBalls(ball1, ball2 int) bool
 	// 抽取一顆球
 	DrawBall() (*game.DrawResult, error)
 	// 抽取額外球
 	DrawExtraBall() (*game.DrawResult, error)
+	// 抽取JP球
+	DrawJPBall() (*game.DrawResult, error)
 	// 獲取已抽出的球
 	GetDrawnBalls() []game.DrawResult
 	// 獲取額外球
 	GetExtraBalls() []game.DrawResult
+	// 獲取JP球
+	GetJPBalls() []game.DrawResult
+	// 選定額外球側邊
+	SelectExtraBallSide(side game.ExtraBallSide) error
+	// 設定本局是否觸發JP
+	SetHasJackpot(hasJackpot bool) error
+	// 記錄JP獲勝者
+	SetJackpotWinner(winnerID string) error
+	// 撤銷最後一顆球
+	UndoLastBall(ballType game.BallType) (*game.DrawResult, error)
+	// 設置投注倒數通知監聽器
+	SetCountdownListener(listener func(game.Countdown))
 }
 
 // gameServiceImpl 實現 GameService 接口
@@ -38,14 +60,25 @@ type gameServiceImpl struct {
 }
 
 // NewGameService 創建一個新的遊戲服務
-func NewGameService(lc fx.Lifecycle, controller *game.DataFlowController) GameService {
+func NewGameService(lc fx.Lifecycle, cfg *config.Config, controller *game.DataFlowController, redisManager redis.RedisManager) GameService {
 	service := &gameServiceImpl{
 		controller: controller,
 	}
 
+	var store *snapshotStore
+	if cfg.Game.SnapshotEnabled {
+		store = newSnapshotStore(redisManager, cfg.Game.SnapshotKey, cfg.Game.SnapshotTTL, cfg.Game.SnapshotFlushInterval)
+	}
+
 	// 設置生命周期鉤子
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			if store != nil {
+				restoreGame(ctx, controller, store)
+				controller.SetChangeListener(store.enqueue)
+				go store.run()
+			}
+
 			log.Println("遊戲服務已初始化，當前狀態:", string(controller.GetCurrentState()))
 
 			// 啟動時自動將狀態從 Agent 切換為 Ready
@@ -63,6 +96,10 @@ func NewGameService(lc fx.Lifecycle, controller *game.DataFlowController) GameSe
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Println("關閉遊戲服務...")
+			if store != nil {
+				controller.SetChangeListener(nil)
+				return store.close(ctx)
+			}
 			return nil
 		},
 	})
@@ -70,6 +107,24 @@ func NewGameService(lc fx.Lifecycle, controller *game.DataFlowController) GameSe
 	return service
 }
 
+// restoreGame 以 Redis 中保存的快照恢復重啟前的遊戲，失敗時沿用新遊戲繼續啟動
+func restoreGame(ctx context.Context, controller *game.DataFlowController, store *snapshotStore) {
+	snapshot, err := store.load(ctx)
+	if err != nil {
+		log.Printf("讀取遊戲快照失敗，以新遊戲啟動: %v", err)
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+
+	if err := controller.Restore(snapshot); err != nil {
+		log.Printf("恢復遊戲快照失敗，以新遊戲啟動: %v", err)
+		return
+	}
+	log.Printf("已恢復遊戲 %s，狀態: %s，已抽出 %d 顆球", snapshot.GameID, snapshot.State, len(snapshot.DrawnBalls))
+}
+
 // GetGameStatus 獲取遊戲當前狀態
 func (s *gameServiceImpl) GetGameStatus() *game.GameStatusResponse {
 	return s.controller.GetGameStatus()
@@ -85,10 +140,24 @@ func (s *gameServiceImpl) ChangeState(state game.GameState) error {
 	return s.controller.ChangeState(state)
 }
 
+// ChangeStateAndGetStatus 更改遊戲狀態並返回轉換後的遊戲狀態
+func (s *gameServiceImpl) ChangeStateAndGetStatus(state game.GameState) (*game.GameStatusResponse, error) {
+	return s.controller.ChangeStateAndGetStatus(state)
+}
+
 // SetJPTriggerNumbers 設置JP觸發號碼
 func (s *gameServiceImpl) SetJPTriggerNumbers(numbers []int) error {
-	s.controller.SetJPTriggerNumbers(numbers)
-	return nil
+	return s.controller.SetJPTriggerNumbers(numbers)
+}
+
+// GenerateLuckyNumbers 隨機產生幸運號碼
+func (s *gameServiceImpl) GenerateLuckyNumbers() ([]int, error) {
+	return s.controller.GenerateLuckyNumbers()
+}
+
+// GetLuckyNumbers 獲取幸運號碼
+func (s *gameServiceImpl) GetLuckyNumbers() []int {
+	return s.controller.GetLuckyNumbers()
 }
 
 // VerifyTwoBalls 驗證兩顆球的有效性
@@ -106,6 +175,11 @@ func (s *gameServiceImpl) DrawExtraBall() (*game.DrawResult, error) {
 	return s.controller.DrawExtraBall()
 }
 
+// DrawJPBall 抽取JP球
+func (s *gameServiceImpl) DrawJPBall() (*game.DrawResult, error) {
+	return s.controller.DrawJPBall()
+}
+
 // GetDrawnBalls 獲取已抽出的球
 func (s *gameServiceImpl) GetDrawnBalls() []game.DrawResult {
 	return s.controller.GetDrawnBalls()
@@ -115,3 +189,33 @@ func (s *gameServiceImpl) GetDrawnBalls() []game.DrawResult {
 func (s *gameServiceImpl) GetExtraBalls() []game.DrawResult {
 	return s.controller.GetExtraBalls()
 }
+
+// SelectExtraBallSide 選定額外球側邊
+func (s *gameServiceImpl) SelectExtraBallSide(side game.ExtraBallSide) error {
+	return s.controller.SelectExtraBallSide(side)
+}
+
+// GetJPBalls 獲取JP球
+func (s *gameServiceImpl) GetJPBalls() []game.DrawResult {
+	return s.controller.GetJPBalls()
+}
+
+// SetHasJackpot 設定本局是否觸發JP
+func (s *gameServiceImpl) SetHasJackpot(hasJackpot bool) error {
+	return s.controller.SetHasJackpot(hasJackpot)
+}
+
+// SetJackpotWinner 記錄JP獲勝者
+func (s *gameServiceImpl) SetJackpotWinner(winnerID string) error {
+	return s.controller.SetJackpotWinner(winnerID)
+}
+
+// UndoLastBall 撤銷最後一顆球
+func (s *gameServiceImpl) UndoLastBall(ballType game.BallType) (*game.DrawResult, error) {
+	return s.controller.UndoLastBall(ballType)
+}
+
+// SetCountdownListener 設置投注倒數通知監聽器
+func (s *gameServiceImpl) SetCountdownListener(listener func(game.Countdown)) {
+	s.controller.SetCountdownListener(listener)
+}
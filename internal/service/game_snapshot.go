@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"g38_lottery_service/game"
+	redis "g38_lottery_service/pkg/redisManager"
+)
+
+// snapshotSaveTimeout 單次寫入快照的時限
+const snapshotSaveTimeout = 3 * time.Second
+
+// snapshotStore 將遊戲快照寫入 Redis，寫入在背景進行且只保留最新一份待寫快照；
+// 設定 flushInterval 時，同一狀態內的變更最多每個間隔寫入一次，狀態轉換時立即寫入
+type snapshotStore struct {
+	redis         redis.RedisManager
+	key           string
+	ttl           time.Duration
+	flushInterval time.Duration
+
+	// 最後寫入的遊戲狀態，僅由背景協程存取
+	lastState game.GameState
+
+	mu      sync.Mutex
+	pending *game.Snapshot
+	signal  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newSnapshotStore 創建快照存儲
+func newSnapshotStore(redisManager redis.RedisManager, key string, ttl, flushInterval time.Duration) *snapshotStore {
+	return &snapshotStore{
+		redis:         redisManager,
+		key:           key,
+		ttl:           ttl,
+		flushInterval: flushInterval,
+		signal:        make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// load 讀取已保存的快照，不存在時返回 nil
+func (s *snapshotStore) load(ctx context.Context) (*game.Snapshot, error) {
+	data, err := s.redis.Get(ctx, s.key)
+	if err != nil {
+		if redis.IsKeyNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load game snapshot: %w", err)
+	}
+
+	var snapshot game.Snapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode game snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// enqueue 排入待寫快照，尚未寫入的舊快照直接被取代；會在控制器持有鎖時調用，不可阻塞
+func (s *snapshotStore) enqueue(snapshot *game.Snapshot) {
+	s.mu.Lock()
+	s.pending = snapshot
+	s.mu.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run 背景寫入快照，直到 close 被調用並寫完最後一份快照
+func (s *snapshotStore) run() {
+	defer close(s.done)
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	stopTimer := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+	}
+
+	for {
+		select {
+		case <-s.signal:
+			if s.flushInterval <= 0 || s.pendingStateChanged() {
+				stopTimer()
+				s.flush()
+				continue
+			}
+			// 同一狀態內的變更延後到間隔結束時合併寫入
+			if timer == nil {
+				timer = time.NewTimer(s.flushInterval)
+				timerC = timer.C
+			}
+		case <-timerC:
+			timer, timerC = nil, nil
+			s.flush()
+		case <-s.stop:
+			stopTimer()
+			s.flush()
+			return
+		}
+	}
+}
+
+// pendingStateChanged 判斷待寫快照的遊戲狀態是否與最後寫入的不同
+func (s *snapshotStore) pendingStateChanged() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pending != nil && s.pending.State != s.lastState
+}
+
+// close 停止背景寫入，等待最後一份快照寫入或 ctx 逾時
+func (s *snapshotStore) close(ctx context.Context) error {
+	close(s.stop)
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for game snapshot flush: %w", ctx.Err())
+	}
+}
+
+// flush 寫入目前待寫的快照
+func (s *snapshotStore) flush() {
+	s.mu.Lock()
+	snapshot := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if snapshot == nil {
+		return
+	}
+	s.lastState = snapshot.State
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Printf("序列化遊戲快照失敗: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotSaveTimeout)
+	defer cancel()
+
+	if err := s.redis.Set(ctx, s.key, data, s.ttl); err != nil {
+		log.Printf("保存遊戲 %s 快照失敗: %v", snapshot.GameID, err)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"g38_lottery_service/game"
+	redis "g38_lottery_service/pkg/redisManager"
+)
+
+// fakeRedis 記錄快照寫入的 Redis 替身，只實現快照存儲使用的方法
+type fakeRedis struct {
+	redis.RedisManager
+
+	mu     sync.Mutex
+	values []string
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.values = append(r.values, string(value.([]byte)))
+	return nil
+}
+
+// saves 返回目前為止寫入的快照
+func (r *fakeRedis) saves(t *testing.T) []game.Snapshot {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make([]game.Snapshot, 0, len(r.values))
+	for _, value := range r.values {
+		var snapshot game.Snapshot
+		if err := json.Unmarshal([]byte(value), &snapshot); err != nil {
+			t.Fatalf("decode saved snapshot: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// waitForSaves 等待寫入次數達到 count
+func (r *fakeRedis) waitForSaves(t *testing.T, count int) []game.Snapshot {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		saves := r.saves(t)
+		if len(saves) >= count {
+			return saves
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d snapshot saves, want %d", len(saves), count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// newPersistedController 創建寫入快照存儲的控制器並推進到抽球階段
+func newPersistedController(t *testing.T, flushInterval time.Duration) (*game.DataFlowController, *snapshotStore, *fakeRedis) {
+	t.Helper()
+
+	controller, err := game.NewDataFlowController(&game.ControllerConfig{RandomSource: game.NewSeededBallSource(1)})
+	if err != nil {
+		t.Fatalf("NewDataFlowController: %v", err)
+	}
+
+	fake := &fakeRedis{}
+	store := newSnapshotStore(fake, "test:snapshot", time.Minute, flushInterval)
+	controller.SetChangeListener(store.enqueue)
+	go store.run()
+
+	// 逐一等待每次狀態轉換寫入，避免連續轉換被合併為最新的一份
+	for i, state := range []game.GameState{game.StateReady, game.StateBetting, game.StateDrawing} {
+		if err := controller.ChangeState(state); err != nil {
+			t.Fatalf("ChangeState(%s): %v", state, err)
+		}
+		fake.waitForSaves(t, i+1)
+	}
+	return controller, store, fake
+}
+
+func drawBalls(t *testing.T, controller *game.DataFlowController, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		if _, err := controller.DrawBall(); err != nil {
+			t.Fatalf("DrawBall: %v", err)
+		}
+	}
+}
+
+func TestSnapshotStoreCoalescesWritesWithinState(t *testing.T) {
+	const flushInterval = 50 * time.Millisecond
+	controller, store, fake := newPersistedController(t, flushInterval)
+	defer store.close(context.Background())
+
+	// 間隔內抽出的球只合併寫入一次
+	drawBalls(t, controller, 5)
+	saves := fake.waitForSaves(t, 4)
+	time.Sleep(3 * flushInterval)
+
+	if saves = fake.saves(t); len(saves) != 4 {
+		t.Fatalf("got %d snapshot saves, want 4", len(saves))
+	}
+	if last := saves[len(saves)-1]; len(last.DrawnBalls) != 5 {
+		t.Fatalf("coalesced snapshot has %d drawn balls, want 5", len(last.DrawnBalls))
+	}
+}
+
+func TestSnapshotStoreFlushesOnStateChange(t *testing.T) {
+	controller, store, fake := newPersistedController(t, time.Hour)
+	defer store.close(context.Background())
+
+	// 同一狀態內的變更延後寫入，狀態轉換時不需等待間隔
+	drawBalls(t, controller, 2)
+	if err := controller.ChangeState(game.StateExtraBet); err != nil {
+		t.Fatalf("ChangeState: %v", err)
+	}
+
+	saves := fake.waitForSaves(t, 4)
+	last := saves[len(saves)-1]
+	if last.State != game.StateExtraBet || len(last.DrawnBalls) != 2 {
+		t.Fatalf("saved snapshot = %s with %d balls, want %s with 2 balls", last.State, len(last.DrawnBalls), game.StateExtraBet)
+	}
+}
+
+func TestSnapshotStoreFlushesOnClose(t *testing.T) {
+	controller, store, fake := newPersistedController(t, time.Hour)
+
+	drawBalls(t, controller, 3)
+	if err := store.close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	saves := fake.saves(t)
+	if len(saves) != 4 {
+		t.Fatalf("got %d snapshot saves after close, want 4", len(saves))
+	}
+	if last := saves[len(saves)-1]; len(last.DrawnBalls) != 3 {
+		t.Fatalf("snapshot flushed on close has %d drawn balls, want 3", len(last.DrawnBalls))
+	}
+}
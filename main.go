@@ -10,8 +10,7 @@ import (
 	_ "g38_lottery_service/docs"
 	"g38_lottery_service/pkg/core"
 	"g38_lottery_service/pkg/utils"
-
-	// "g38_lottery_service/pkg/websocket" // 註釋掉，避免衝突
+	"g38_lottery_service/pkg/websocket"
 
 	"go.uber.org/fx"
 )
@@ -39,14 +38,17 @@ func main() {
 	}
 
 	app := fx.New(
+		// 依各關閉階段的超時設定整體關閉時限
+		fx.StopTimeout(config.LoadShutdownConfig().StopTimeout()),
+
 		core.Module,
 
 		config.Module,
 		service.Module,
 		handler.Module,
 
-		// 註釋掉重複的 WebSocket 模組
-		// websocket.Module,
+		// 玩家端 WebSocket 管理器，連接經由 API 服務器或玩家端口的 /ws 路由轉交
+		websocket.Module,
 	)
 
 	app.Run()
@@ -28,6 +28,8 @@ var DatabaseModule = fx.Options(
 					Charset:   "utf8mb4",
 					ParseTime: true,
 					Loc:       "Local",
+
+					CloseTimeout: cfg.Shutdown.CloseTimeout,
 				}
 			},
 			fx.ResultTags(`name:"mysqlConfig"`),
@@ -48,10 +50,11 @@ var RedisModule = fx.Options(
 		// 提供 Redis 配置
 		func(cfg *config.Config) *redis.RedisConfig {
 			return &redis.RedisConfig{
-				Addr:     cfg.Redis.Addr,
-				Username: cfg.Redis.Username,
-				Password: cfg.Redis.Password,
-				DB:       cfg.Redis.DB,
+				Addr:         cfg.Redis.Addr,
+				Username:     cfg.Redis.Username,
+				Password:     cfg.Redis.Password,
+				DB:           cfg.Redis.DB,
+				CloseTimeout: cfg.Shutdown.CloseTimeout,
 			}
 		},
 		// 提供 Redis 客戶端和管理器
@@ -64,12 +67,25 @@ var RedisModule = fx.Options(
 var WebSocketModule = fx.Options(
 	fx.Provide(
 		// 提供 WebSocket 管理器，使用空的驗證函數
-		func() *dealerWebsocket.Manager {
+		func(cfg *config.Config) *dealerWebsocket.Manager {
 			// 使用一個始終返回成功的驗證函數
 			tokenValidator := func(token string) (uint, error) {
 				return 1, nil // 假設用戶ID為1
 			}
-			return dealerWebsocket.NewManager(tokenValidator)
+			manager := dealerWebsocket.NewManager(tokenValidator)
+			manager.SetRejectDuplicateDealers(cfg.Server.RejectDupDealer)
+			manager.SetConfig(dealerWebsocket.Config{
+				ReadLimit:    cfg.WebSocket.ReadLimit,
+				ReadTimeout:  cfg.WebSocket.ReadTimeout,
+				PingInterval: cfg.WebSocket.PingInterval,
+				WriteTimeout: cfg.WebSocket.WriteTimeout,
+
+				EnableCompression: cfg.WebSocket.EnableCompression,
+				CompressionLevel:  cfg.WebSocket.CompressionLevel,
+
+				AllowedOrigins: cfg.WebSocket.AllowedOrigins,
+			})
+			return manager
 		},
 		// 提供 WebSocket 處理程序，使用空的驗證函數
 		func(manager *dealerWebsocket.Manager) *dealerWebsocket.WebSocketHandler {
@@ -82,14 +98,14 @@ var WebSocketModule = fx.Options(
 	),
 	// 啟動 WebSocket 管理器
 	fx.Invoke(
-		func(lc fx.Lifecycle, manager *dealerWebsocket.Manager) {
+		func(lc fx.Lifecycle, cfg *config.Config, manager *dealerWebsocket.Manager) {
 			lc.Append(fx.Hook{
 				OnStart: func(ctx context.Context) error {
 					go manager.Start(ctx)
 					return nil
 				},
 				OnStop: func(ctx context.Context) error {
-					manager.Shutdown()
+					manager.ShutdownWithTimeout(cfg.Shutdown.NotifyTimeout)
 					return nil
 				},
 			})
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"g38_lottery_service/pkg/utils"
+
 	"go.uber.org/fx"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -20,6 +22,8 @@ type MySQLConfig struct {
 	Charset   string
 	ParseTime bool
 	Loc       string
+
+	CloseTimeout time.Duration // 關閉連接的時限，0 表示不限制
 }
 
 // mysqlManagerImpl 是 DatabaseManager 介面的實作
@@ -235,7 +239,7 @@ func ProvideMySQLDatabaseManager(lc fx.Lifecycle, config *MySQLConfig) (Database
 		},
 		OnStop: func(ctx context.Context) error {
 			fmt.Println("Closing MySQL database connection...")
-			return manager.Close()
+			return utils.CloseWithTimeout("MySQL connection", manager.Close, config.CloseTimeout)
 		},
 	})
 
@@ -6,6 +6,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"g38_lottery_service/pkg/utils"
+
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -28,11 +30,10 @@ func NewWebSocketHandler(manager *Manager, authFunc func(token string) (uint, er
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
-		// 允許所有源的連接
-		CheckOrigin: func(r *http.Request) bool {
-			// 在生產環境中應該實現更嚴格的檢查
-			return true
-		},
+		// 僅在客戶端聲明支援時才會協商壓縮
+		EnableCompression: manager.config.EnableCompression,
+		// 依設定的來源白名單檢查連接
+		CheckOrigin: utils.NewOriginChecker(manager.config.AllowedOrigins),
 	}
 
 	return &WebSocketHandler{
@@ -52,6 +53,13 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// 已協商壓縮時設置壓縮等級
+	if h.upgrader.EnableCompression {
+		if err := conn.SetCompressionLevel(h.manager.config.CompressionLevel); err != nil {
+			log.Printf("Dealer WebSocket Handler: Invalid compression level %d: %v", h.manager.config.CompressionLevel, err)
+		}
+	}
+
 	// 生成客戶端唯一標識
 	clientID := uuid.New().String()
 	log.Printf("Dealer WebSocket Handler: New connection from %s, assigned ID: %s", conn.RemoteAddr(), clientID)
@@ -64,6 +72,8 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		manager:      h.manager,
 		LastActivity: time.Now(),
 		IsAuthed:     false, // 初始未認證
+		closeChan:    make(chan struct{}),
+		writeDone:    make(chan struct{}),
 	}
 
 	// 增加連接計數
@@ -76,6 +86,9 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	// 啟動客戶端讀寫協程
 	go client.ReadPump()
 	go client.WritePump()
+
+	// 未在時限內完成認證的連接將被斷開
+	client.enforceAuthTimeout(authTimeout)
 }
 
 // 獲取當前活躍連接數
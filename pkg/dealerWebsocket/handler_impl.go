@@ -0,0 +1,244 @@
+package dealerWebsocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/service"
+)
+
+// 荷官業務消息類型常量
+const (
+	// 荷官端請求
+	MessageTypeGetStatus  = "GET_STATUS"   // 查詢當前遊戲狀態
+	MessageTypeDrawJPBall = "DRAW_JP_BALL" // 抽取JP球
+
+	MessageTypeSelectExtraBallSide = "SELECT_EXTRA_BALL_SIDE" // 選定額外球側邊
+	MessageTypeNotifyJackpotWinner = "NOTIFY_JACKPOT_WINNER"  // 通知JP獲勝者
+	MessageTypeSetHasJackpot       = "SET_HAS_JACKPOT"        // 設定本局是否觸發JP
+
+	// 服務端回應與通知
+	MessageTypeGameStatus  = "GAME_STATUS"   // 當前遊戲狀態快照（僅回應請求者）
+	MessageTypeJPBallDrawn = "JP_BALL_DRAWN" // JP球已抽出（廣播）
+
+	MessageTypeExtraBallSideSelected = "EXTRA_BALL_SIDE_SELECTED" // 額外球側邊已選定（廣播）
+	MessageTypeJackpotWinnerNotified = "JACKPOT_WINNER_NOTIFIED"  // JP獲勝者已記錄（廣播）
+	MessageTypeHasJackpotChanged     = "HAS_JACKPOT_CHANGED"      // 本局JP觸發設定已變更（廣播）
+	MessageTypeCountdown             = "COUNTDOWN"                // 狀態倒數剩餘秒數（廣播）
+	MessageTypeGameError             = "GAME_ERROR"               // 抽球失敗通知（廣播）
+)
+
+// 選定額外球側邊請求與通知
+type ExtraBallSideMessage struct {
+	Side game.ExtraBallSide `json:"side"` // LEFT 或 RIGHT
+}
+
+// 設定本局是否觸發JP請求與通知
+type HasJackpotMessage struct {
+	HasJackpot bool `json:"hasJackpot"`
+}
+
+// 通知JP獲勝者請求
+type NotifyJackpotWinnerMessage struct {
+	WinnerID string `json:"winnerId"` // 獲勝者ID
+}
+
+// JP獲勝者已記錄通知
+type JackpotWinnerNotifiedMessage struct {
+	WinnerID string           `json:"winnerId"` // 獲勝者ID
+	Jackpot  game.JackpotInfo `json:"jackpot"`  // 更新後的JP資訊
+}
+
+// 抽球失敗通知，讓其他荷官端得知需要重新抽球
+type GameErrorMessage struct {
+	Operation string `json:"operation"` // 失敗的操作，例如 DRAW_JP_BALL
+	Code      string `json:"code"`      // 機器可讀的錯誤代碼，例如 BALLS_EXHAUSTED
+	Message   string `json:"message"`   // 錯誤信息
+}
+
+// JP球抽出通知
+type JPBallDrawnMessage struct {
+	BallNumber int `json:"ballNumber"` // 球號
+	OrderIndex int `json:"orderIndex"` // 抽出順序
+	TotalDrawn int `json:"totalDrawn"` // 已抽出的JP球數
+}
+
+// DealerMessageHandler 處理荷官端發送的業務消息
+type DealerMessageHandler struct {
+	gameService service.GameService
+
+	// 遊戲錯誤通知的額外接收者，例如轉發給玩家端房間
+	gameErrorListener func(message *BasicMessage)
+}
+
+// 創建荷官消息處理器
+func NewDealerMessageHandler(gameService service.GameService) *DealerMessageHandler {
+	return &DealerMessageHandler{
+		gameService: gameService,
+	}
+}
+
+// 設置遊戲錯誤通知的接收者，須在處理器註冊到管理器之前調用
+func (h *DealerMessageHandler) SetGameErrorListener(listener func(message *BasicMessage)) {
+	h.gameErrorListener = listener
+}
+
+// 處理接收到的消息
+func (h *DealerMessageHandler) HandleMessage(client *Client, messageType string, data interface{}) {
+	switch messageType {
+	case MessageTypeGetStatus:
+		h.handleGetStatus(client)
+	case MessageTypeDrawJPBall:
+		h.handleDrawJPBall(client)
+	case MessageTypeSelectExtraBallSide:
+		h.handleSelectExtraBallSide(client, data)
+	case MessageTypeNotifyJackpotWinner:
+		h.handleNotifyJackpotWinner(client, data)
+	case MessageTypeSetHasJackpot:
+		h.handleSetHasJackpot(client, data)
+	default:
+		log.Printf("Dealer Message Handler: Unknown message type %s from client %s\n", messageType, client.ID)
+		h.sendError(client, 400, fmt.Sprintf("unknown message type: %s", messageType))
+	}
+}
+
+// 處理客戶端連接成功事件
+func (h *DealerMessageHandler) HandleConnect(client *Client) {
+	log.Printf("Dealer Message Handler: Client %s connected\n", client.ID)
+}
+
+// 處理客戶端斷開連接事件
+func (h *DealerMessageHandler) HandleDisconnect(client *Client) {
+	log.Printf("Dealer Message Handler: Client %s disconnected\n", client.ID)
+}
+
+// 回應當前遊戲狀態，只發送給請求的客戶端，不廣播
+func (h *DealerMessageHandler) handleGetStatus(client *Client) {
+	status := h.gameService.GetGameStatus()
+	client.sendMessage(NewMessage(MessageTypeGameStatus, status))
+}
+
+// 抽取JP球並廣播給所有荷官端，狀態檢查由 DrawJPBall 負責
+func (h *DealerMessageHandler) handleDrawJPBall(client *Client) {
+	result, err := h.gameService.DrawJPBall()
+	if err != nil {
+		log.Printf("Dealer Message Handler: Client %s failed to draw JP ball: %v\n", client.ID, err)
+		h.sendGameError(client, err)
+		// 狀態不符是請求者的操作錯誤，只回應請求者
+		if !errors.Is(err, game.ErrInvalidState) {
+			h.broadcastGameError(client, MessageTypeDrawJPBall, err)
+		}
+		return
+	}
+
+	notification := JPBallDrawnMessage{
+		BallNumber: result.BallNumber,
+		OrderIndex: result.OrderIndex,
+		TotalDrawn: len(h.gameService.GetJPBalls()),
+	}
+
+	if err := client.manager.BroadcastToAll(NewMessage(MessageTypeJPBallDrawn, notification)); err != nil {
+		log.Printf("Dealer Message Handler: Failed to broadcast JP ball %d: %v\n", result.BallNumber, err)
+	}
+}
+
+// 選定額外球側邊並廣播給所有荷官端
+func (h *DealerMessageHandler) handleSelectExtraBallSide(client *Client, data interface{}) {
+	var req ExtraBallSideMessage
+	if err := decodeMessageData(data, &req); err != nil {
+		h.sendError(client, 400, fmt.Sprintf("invalid %s message: %v", MessageTypeSelectExtraBallSide, err))
+		return
+	}
+
+	if err := h.gameService.SelectExtraBallSide(req.Side); err != nil {
+		log.Printf("Dealer Message Handler: Client %s failed to select extra ball side: %v\n", client.ID, err)
+		h.sendGameError(client, err)
+		return
+	}
+
+	if err := client.manager.BroadcastToAll(NewMessage(MessageTypeExtraBallSideSelected, ExtraBallSideMessage{Side: req.Side})); err != nil {
+		log.Printf("Dealer Message Handler: Failed to broadcast extra ball side %s: %v\n", req.Side, err)
+	}
+}
+
+// 設定本局是否觸發JP並廣播給所有荷官端
+func (h *DealerMessageHandler) handleSetHasJackpot(client *Client, data interface{}) {
+	var req HasJackpotMessage
+	if err := decodeMessageData(data, &req); err != nil {
+		h.sendError(client, 400, fmt.Sprintf("invalid %s message: %v", MessageTypeSetHasJackpot, err))
+		return
+	}
+
+	if err := h.gameService.SetHasJackpot(req.HasJackpot); err != nil {
+		log.Printf("Dealer Message Handler: Client %s failed to set jackpot flag: %v\n", client.ID, err)
+		h.sendGameError(client, err)
+		return
+	}
+
+	if err := client.manager.BroadcastToAll(NewMessage(MessageTypeHasJackpotChanged, HasJackpotMessage{HasJackpot: req.HasJackpot})); err != nil {
+		log.Printf("Dealer Message Handler: Failed to broadcast jackpot flag %v: %v\n", req.HasJackpot, err)
+	}
+}
+
+// 記錄JP獲勝者並廣播給所有荷官端
+func (h *DealerMessageHandler) handleNotifyJackpotWinner(client *Client, data interface{}) {
+	var req NotifyJackpotWinnerMessage
+	if err := decodeMessageData(data, &req); err != nil {
+		h.sendError(client, 400, fmt.Sprintf("invalid %s message: %v", MessageTypeNotifyJackpotWinner, err))
+		return
+	}
+
+	if err := h.gameService.SetJackpotWinner(req.WinnerID); err != nil {
+		log.Printf("Dealer Message Handler: Client %s failed to notify jackpot winner: %v\n", client.ID, err)
+		h.sendGameError(client, err)
+		return
+	}
+
+	notification := JackpotWinnerNotifiedMessage{
+		WinnerID: req.WinnerID,
+		Jackpot:  h.gameService.GetGameStatus().Jackpot,
+	}
+
+	if err := client.manager.BroadcastToAll(NewMessage(MessageTypeJackpotWinnerNotified, notification)); err != nil {
+		log.Printf("Dealer Message Handler: Failed to broadcast jackpot winner %s: %v\n", req.WinnerID, err)
+	}
+}
+
+// 將消息數據解析到指定結構
+func decodeMessageData(data interface{}, v interface{}) error {
+	raw, ok := data.(json.RawMessage)
+	if !ok || len(raw) == 0 {
+		return fmt.Errorf("missing data")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// 依遊戲錯誤分類發送錯誤回應，錯誤碼與 HTTP API 一致
+func (h *DealerMessageHandler) sendGameError(client *Client, err error) {
+	h.sendError(client, game.HTTPStatus(err), err.Error())
+}
+
+// 廣播抽球失敗通知給其他荷官端並轉交給遊戲錯誤接收者，請求者已由 sendGameError 收到錯誤回應
+func (h *DealerMessageHandler) broadcastGameError(client *Client, operation string, err error) {
+	notification := GameErrorMessage{
+		Operation: operation,
+		Code:      game.ErrorCode(err),
+		Message:   err.Error(),
+	}
+	message := NewMessage(MessageTypeGameError, notification)
+
+	if broadcastErr := client.manager.BroadcastToOthers(client, message); broadcastErr != nil {
+		log.Printf("Dealer Message Handler: Failed to broadcast %s error: %v\n", operation, broadcastErr)
+	}
+	if h.gameErrorListener != nil {
+		h.gameErrorListener(message)
+	}
+}
+
+// 發送錯誤回應給客戶端
+func (h *DealerMessageHandler) sendError(client *Client, code int, message string) {
+	client.sendMessage(NewErrorMessage(code, message))
+}
@@ -0,0 +1,218 @@
+package dealerWebsocket
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"g38_lottery_service/game"
+	"g38_lottery_service/internal/service"
+)
+
+// fakeGameService 以真實控制器實現狀態查詢與抽JP球相關方法的遊戲服務替身，未實現的方法被調用時會 panic
+type fakeGameService struct {
+	service.GameService
+
+	controller *game.DataFlowController
+}
+
+func (s *fakeGameService) DrawJPBall() (*game.DrawResult, error) {
+	return s.controller.DrawJPBall()
+}
+
+func (s *fakeGameService) GetJPBalls() []game.DrawResult {
+	return s.controller.GetJPBalls()
+}
+
+func (s *fakeGameService) GetGameStatus() *game.GameStatusResponse {
+	return s.controller.GetGameStatus()
+}
+
+// newFakeGameService 創建遊戲服務替身並依序轉換到指定狀態
+func newFakeGameService(t *testing.T, states ...game.GameState) *fakeGameService {
+	t.Helper()
+
+	controller, err := game.NewDataFlowController(&game.ControllerConfig{RandomSource: game.NewSeededBallSource(1)})
+	if err != nil {
+		t.Fatalf("NewDataFlowController: %v", err)
+	}
+	for _, state := range states {
+		if err := controller.ChangeState(state); err != nil {
+			t.Fatalf("ChangeState(%s): %v", state, err)
+		}
+	}
+	return &fakeGameService{controller: controller}
+}
+
+// jpDrawingStates 從初始狀態進入JP抽球階段的狀態序列
+var jpDrawingStates = []game.GameState{
+	game.StateReady, game.StateBetting, game.StateDrawing,
+	game.StateJPStandby, game.StateJPBetting, game.StateJPDrawing,
+}
+
+// startDealerGame 啟動掛載荷官消息處理器的測試服務器，返回兩個已認證的荷官連接
+func startDealerGame(t *testing.T, gameService service.GameService) (*Manager, *testConn, *testConn) {
+	t.Helper()
+
+	manager, _, server := startDealerServer(t)
+	manager.SetMessageHandler(NewDealerMessageHandler(gameService))
+
+	requester := dialDealer(t, server)
+	other := dialDealer(t, server)
+	authenticate(t, requester, "dealer-1")
+	authenticate(t, other, "dealer-2")
+	return manager, requester, other
+}
+
+// readError 讀取錯誤回應
+func readError(t *testing.T, conn *testConn) ErrorMessage {
+	t.Helper()
+
+	var payload ErrorMessage
+	if err := json.Unmarshal(readMessage(t, conn, MessageTypeError).Data, &payload); err != nil {
+		t.Fatalf("decode error message: %v", err)
+	}
+	return payload
+}
+
+// assertNoMessageBefore 廣播一條標記消息，確認在標記之前沒有收到指定類型的消息
+func assertNoMessageBefore(t *testing.T, manager *Manager, conn *testConn, messageType string) {
+	t.Helper()
+
+	broadcastSeq(t, manager, 1)
+	for _, msg := range readMessagesUntil(t, conn, "test_broadcast") {
+		if msg.Type == messageType {
+			t.Fatalf("received unexpected %s message", messageType)
+		}
+	}
+}
+
+func TestDrawJPBallBroadcastsResult(t *testing.T) {
+	_, requester, other := startDealerGame(t, newFakeGameService(t, jpDrawingStates...))
+
+	sendJSON(t, requester, map[string]string{"type": MessageTypeDrawJPBall})
+
+	for _, conn := range []*testConn{requester, other} {
+		var drawn JPBallDrawnMessage
+		if err := json.Unmarshal(readMessage(t, conn, MessageTypeJPBallDrawn).Data, &drawn); err != nil {
+			t.Fatalf("decode %s: %v", MessageTypeJPBallDrawn, err)
+		}
+		if drawn.OrderIndex != 1 || drawn.TotalDrawn != 1 || drawn.BallNumber < 1 || drawn.BallNumber > 75 {
+			t.Fatalf("JP ball drawn = %+v, want the first ball in 1-75", drawn)
+		}
+	}
+}
+
+func TestDrawJPBallRejectedInWrongState(t *testing.T) {
+	manager, requester, other := startDealerGame(t, newFakeGameService(t, game.StateReady, game.StateBetting))
+
+	// 替身未實現 GetCurrentState，處理器必須直接由 DrawJPBall 回報狀態錯誤
+	sendJSON(t, requester, map[string]string{"type": MessageTypeDrawJPBall})
+
+	payload := readError(t, requester)
+	if payload.Code != 409 || !strings.Contains(payload.Message, string(game.StateBetting)) {
+		t.Fatalf("error = %+v, want 409 naming state %s", payload, game.StateBetting)
+	}
+
+	// 狀態錯誤只回應請求者，不通知其他荷官
+	assertNoMessageBefore(t, manager, other, MessageTypeGameError)
+}
+
+func TestDrawJPBallFailureBroadcastToOtherDealers(t *testing.T) {
+	gameService := newFakeGameService(t, jpDrawingStates...)
+	for i := 0; i < 30; i++ {
+		if _, err := gameService.controller.DrawJPBall(); err != nil {
+			t.Fatalf("DrawJPBall %d: %v", i+1, err)
+		}
+	}
+	manager, requester, other := startDealerGame(t, gameService)
+
+	// JP抽球數已達上限，請求者收到錯誤回應
+	sendJSON(t, requester, map[string]string{"type": MessageTypeDrawJPBall})
+	if payload := readError(t, requester); payload.Code != 409 {
+		t.Fatalf("error code = %d, want 409", payload.Code)
+	}
+
+	// 其他荷官收到失敗通知
+	var notification GameErrorMessage
+	if err := json.Unmarshal(readMessage(t, other, MessageTypeGameError).Data, &notification); err != nil {
+		t.Fatalf("decode %s: %v", MessageTypeGameError, err)
+	}
+	if notification.Operation != MessageTypeDrawJPBall || notification.Code != "BALLS_EXHAUSTED" {
+		t.Fatalf("game error = %+v, want DRAW_JP_BALL BALLS_EXHAUSTED", notification)
+	}
+
+	// 請求者只收到一次錯誤，不再收到廣播的失敗通知
+	assertNoMessageBefore(t, manager, requester, MessageTypeGameError)
+}
+
+func TestDrawJPBallFailureNotifiesGameErrorListener(t *testing.T) {
+	// 停在 JP_BETTING，先驗證狀態錯誤
+	gameService := newFakeGameService(t, jpDrawingStates[:len(jpDrawingStates)-1]...)
+	manager, _, server := startDealerServer(t)
+	messageHandler := NewDealerMessageHandler(gameService)
+	notified := make(chan *BasicMessage, 2)
+	messageHandler.SetGameErrorListener(func(message *BasicMessage) { notified <- message })
+	manager.SetMessageHandler(messageHandler)
+
+	dealer := dialDealer(t, server)
+	authenticate(t, dealer, "dealer-1")
+
+	// 狀態錯誤只回應請求者，不通知接收者
+	sendJSON(t, dealer, map[string]string{"type": MessageTypeDrawJPBall})
+	readError(t, dealer)
+	select {
+	case message := <-notified:
+		t.Fatalf("listener notified of %+v for an invalid state error", message)
+	default:
+	}
+
+	// JP抽球數達上限後的失敗通知轉交給接收者
+	if err := gameService.controller.ChangeState(game.StateJPDrawing); err != nil {
+		t.Fatalf("ChangeState(%s): %v", game.StateJPDrawing, err)
+	}
+	for i := 0; i < 30; i++ {
+		if _, err := gameService.controller.DrawJPBall(); err != nil {
+			t.Fatalf("DrawJPBall %d: %v", i+1, err)
+		}
+	}
+	sendJSON(t, dealer, map[string]string{"type": MessageTypeDrawJPBall})
+	readError(t, dealer)
+
+	select {
+	case message := <-notified:
+		notification, ok := message.Data.(GameErrorMessage)
+		if message.Type != MessageTypeGameError || !ok || notification.Code != "BALLS_EXHAUSTED" {
+			t.Fatalf("listener message = %+v, want %s BALLS_EXHAUSTED", message, MessageTypeGameError)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener not notified of the exhausted JP ball pool")
+	}
+}
+
+func TestGetStatusRepliesToRequesterOnly(t *testing.T) {
+	gameService := newFakeGameService(t, game.StateReady, game.StateBetting, game.StateDrawing)
+	drawn, err := gameService.controller.DrawBall()
+	if err != nil {
+		t.Fatalf("DrawBall: %v", err)
+	}
+	manager, requester, other := startDealerGame(t, gameService)
+
+	sendJSON(t, requester, map[string]string{"type": MessageTypeGetStatus})
+
+	var status game.GameStatusResponse
+	if err := json.Unmarshal(readMessage(t, requester, MessageTypeGameStatus).Data, &status); err != nil {
+		t.Fatalf("decode %s: %v", MessageTypeGameStatus, err)
+	}
+	want := gameService.controller.GetGameStatus()
+	if status.Game.ID != want.Game.ID || status.Game.State != string(game.StateDrawing) {
+		t.Fatalf("status game = %+v, want game %s in %s", status.Game, want.Game.ID, game.StateDrawing)
+	}
+	if len(status.DrawnBalls) != 1 || status.DrawnBalls[0].Number != drawn.BallNumber {
+		t.Fatalf("status drawn balls = %+v, want ball %d", status.DrawnBalls, drawn.BallNumber)
+	}
+
+	// 狀態查詢只回應請求者，不廣播
+	assertNoMessageBefore(t, manager, other, MessageTypeGameStatus)
+}
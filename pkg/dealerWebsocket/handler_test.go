@@ -0,0 +1,59 @@
+package dealerWebsocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWithCompression 以聲明支援壓縮的客戶端連接指定壓縮設定的服務器，返回連接與握手回應
+func dialWithCompression(t *testing.T, config Config) (*testConn, *http.Response) {
+	t.Helper()
+
+	manager := NewManager(testAuth)
+	manager.SetConfig(config)
+	handler := NewWebSocketHandler(manager, testAuth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go manager.Start(ctx)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+	})
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{Conn: conn}, resp
+}
+
+func TestCompressionNegotiatedWhenEnabled(t *testing.T) {
+	for name, level := range map[string]int{"default level": 0, "best speed": 1, "best compression": 9} {
+		t.Run(name, func(t *testing.T) {
+			conn, resp := dialWithCompression(t, Config{EnableCompression: true, CompressionLevel: level})
+
+			if ext := resp.Header.Get("Sec-Websocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+				t.Fatalf("Sec-WebSocket-Extensions = %q, want permessage-deflate", ext)
+			}
+			// 壓縮後的消息仍可正常往返
+			authenticate(t, conn, "dealer-1")
+		})
+	}
+}
+
+func TestCompressionAbsentWhenDisabled(t *testing.T) {
+	conn, resp := dialWithCompression(t, Config{})
+
+	if ext := resp.Header.Get("Sec-Websocket-Extensions"); ext != "" {
+		t.Fatalf("Sec-WebSocket-Extensions = %q, want no extension negotiated", ext)
+	}
+	authenticate(t, conn, "dealer-1")
+}
@@ -1,11 +1,14 @@
 package dealerWebsocket
 
 import (
+	"compress/flate"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -30,8 +33,73 @@ const (
 
 	// 消息最大大小
 	maxMessageSize = 512
+
+	// 連接後必須完成認證的時限
+	authTimeout = 10 * time.Second
+
+	// 客戶端註銷時送出剩餘佇列訊息的最長時間
+	sendDrainTimeout = 2 * time.Second
+
+	// 廣播通道已滿時等待入隊的最長時間
+	broadcastEnqueueTimeout = 100 * time.Millisecond
+	// 廣播循環停滯檢查間隔與判定閾值
+	broadcastWatchdogInterval = 5 * time.Second
+	broadcastStallThreshold   = 10 * time.Second
+)
+
+var (
+	// 廣播通道已滿，消息被丟棄
+	ErrBroadcastQueueFull = errors.New("broadcast queue is full")
+	// 已有其他荷官連接在操作遊戲
+	ErrDuplicateDealer = errors.New("another dealer connection is already active")
 )
 
+// 連接讀寫限制與超時設定，未設置的欄位使用預設值
+type Config struct {
+	ReadLimit    int64         // 單條訊息最大讀取大小（位元組）
+	ReadTimeout  time.Duration // 讀取超時，收到訊息或 pong 後重新計算
+	PingInterval time.Duration // 心跳（ping）發送間隔，應小於 ReadTimeout
+	WriteTimeout time.Duration // 寫入超時
+
+	EnableCompression bool // 是否與支援的客戶端協商 permessage-deflate 壓縮
+	CompressionLevel  int  // 壓縮等級（flate 等級，-2 至 9），0 使用預設等級
+
+	AllowedOrigins []string // 允許連接的來源，nil 表示不檢查，"*" 表示允許所有來源
+}
+
+// 預設的連接讀寫設定
+func DefaultConfig() Config {
+	return Config{
+		ReadLimit:    4096, // 4KB
+		ReadTimeout:  readTimeout,
+		PingInterval: heartbeatInterval,
+		WriteTimeout: writeTimeout,
+
+		CompressionLevel: flate.DefaultCompression,
+	}
+}
+
+// 以預設值補齊未設置的欄位
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.ReadLimit <= 0 {
+		c.ReadLimit = defaults.ReadLimit
+	}
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = defaults.ReadTimeout
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = defaults.PingInterval
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaults.WriteTimeout
+	}
+	if c.CompressionLevel == 0 {
+		c.CompressionLevel = defaults.CompressionLevel
+	}
+	return c
+}
+
 // 心跳消息結構
 type HeartbeatMessage struct {
 	Type      string `json:"type"`      // 消息類型
@@ -47,9 +115,17 @@ type Client struct {
 	manager         *Manager        // 所屬的管理器
 	LastActivity    time.Time       // 最後活動時間
 	IsAuthed        bool            // 是否已認證
+	DuplicateDealer bool            // 是否為重複的荷官連接（認證時已有其他荷官在線）
+	authedAt        time.Time       // 認證完成時間，用於主要荷官離線後決定接替者
 	closeChan       chan struct{}   // 關閉通道
 	heartbeatTicker *time.Ticker    // 心跳定時器
 	connMutex       sync.Mutex      // 連接鎖，防止並發讀寫
+
+	closeOnce   sync.Once     // 確保關閉信號與發送通道只關閉一次
+	closeReason string        // 關閉幀附帶的原因，僅在關閉時設定一次
+	sendMutex   sync.Mutex    // 保護發送通道的寫入與關閉
+	sendClosed  bool          // 發送通道是否已關閉
+	writeDone   chan struct{} // WritePump 結束時關閉，用於等待關閉幀送出
 }
 
 // 客戶端狀態
@@ -79,7 +155,13 @@ type Message struct {
 	// 客戶端
 	Client *Client
 	// 數據
-	Data []byte
+	Data json.RawMessage
+}
+
+// 待廣播的消息，exclude 不為空時跳過該客戶端
+type outboundMessage struct {
+	data    []byte
+	exclude *Client
 }
 
 // WebSocket 管理器結構體
@@ -91,10 +173,17 @@ type Manager struct {
 	messageHandler  MessageHandler
 	register        chan *Client
 	unregister      chan *Client
-	broadcast       chan []byte
+	broadcast       chan *outboundMessage
 	shutdown        chan struct{}
 	auth            func(token string) (uint, error)
 	mutex           sync.RWMutex
+
+	droppedBroadcasts int64 // 因通道已滿而丟棄的廣播數量
+	lastLoopActivity  int64 // 主事件循環最後一次處理事件的時間（UnixNano）
+
+	rejectDuplicateDealers bool // 是否拒絕第二個荷官連接，否則僅記錄警告
+
+	config Config // 連接讀寫限制與超時設定
 }
 
 // 創建新的 WebSocket 管理器
@@ -107,13 +196,22 @@ func NewManager(authFunc func(token string) (uint, error)) *Manager {
 		messageHandler:  nil,
 		register:        make(chan *Client, 10),
 		unregister:      make(chan *Client, 10),
-		broadcast:       make(chan []byte, 100),
+		broadcast:       make(chan *outboundMessage, 100),
 		shutdown:        make(chan struct{}),
 		auth:            authFunc,
 		mutex:           sync.RWMutex{},
+		config:          DefaultConfig(),
 	}
 }
 
+// 設置連接讀寫限制與超時，需在 Start 與接受連接之前調用
+func (manager *Manager) SetConfig(config Config) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.config = config.withDefaults()
+}
+
 // 啟動 WebSocket 管理器
 func (manager *Manager) Start(ctx context.Context) {
 	log.Println("Dealer WebSocket Manager: Starting...")
@@ -137,10 +235,17 @@ func (manager *Manager) Start(ctx context.Context) {
 	inactivityTicker := time.NewTicker(1 * time.Minute)
 	defer inactivityTicker.Stop()
 
+	// 啟動廣播循環看門狗
+	watchdogCtx, stopWatchdog := context.WithCancel(ctx)
+	defer stopWatchdog()
+	go manager.watchBroadcastLoop(watchdogCtx)
+
 	log.Println("Dealer WebSocket Manager: Running main event loop")
 	running := true
 
 	for running {
+		atomic.StoreInt64(&manager.lastLoopActivity, time.Now().UnixNano())
+
 		select {
 		case <-ctx.Done():
 			log.Println("Dealer WebSocket Manager: Context cancelled, shutting down...")
@@ -158,6 +263,10 @@ func (manager *Manager) Start(ctx context.Context) {
 			manager.mutex.Unlock()
 			log.Printf("Dealer WebSocket Manager: Client %s registered\n", client.ID)
 
+			if handler := manager.getMessageHandler(); handler != nil {
+				handler.HandleConnect(client)
+			}
+
 		case client, ok := <-manager.unregister:
 			if !ok {
 				log.Println("Dealer WebSocket Manager: Unregister channel closed")
@@ -166,13 +275,17 @@ func (manager *Manager) Start(ctx context.Context) {
 
 			manager.removeClient(client)
 
+			if handler := manager.getMessageHandler(); handler != nil {
+				handler.HandleDisconnect(client)
+			}
+
 		case message, ok := <-manager.broadcast:
 			if !ok {
 				log.Println("Dealer WebSocket Manager: Broadcast channel closed")
 				continue
 			}
 
-			manager.broadcastMessage(message)
+			manager.broadcastMessage(message.data, message.exclude)
 
 		case <-inactivityTicker.C:
 			manager.cleanupInactiveConnections()
@@ -182,6 +295,30 @@ func (manager *Manager) Start(ctx context.Context) {
 	log.Println("Dealer WebSocket Manager: Event loop terminated")
 }
 
+// 監控主事件循環，若廣播通道有積壓但循環長時間未處理則記錄警告
+func (manager *Manager) watchBroadcastLoop(ctx context.Context) {
+	ticker := time.NewTicker(broadcastWatchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pending := len(manager.broadcast)
+			if pending == 0 {
+				continue
+			}
+
+			lastActivity := time.Unix(0, atomic.LoadInt64(&manager.lastLoopActivity))
+			if stalled := time.Since(lastActivity); stalled > broadcastStallThreshold {
+				log.Printf("Dealer WebSocket Manager: Broadcast loop stalled for %v with %d pending messages (dropped %d)",
+					stalled, pending, atomic.LoadInt64(&manager.droppedBroadcasts))
+			}
+		}
+	}
+}
+
 // 清理所有連接
 func (manager *Manager) cleanupAllConnections() {
 	manager.mutex.Lock()
@@ -190,16 +327,8 @@ func (manager *Manager) cleanupAllConnections() {
 	log.Printf("Dealer WebSocket Manager: Cleaning up all %d connections", len(manager.clients))
 
 	for client := range manager.clients {
-		if client.heartbeatTicker != nil {
-			client.heartbeatTicker.Stop()
-		}
-
-		if client.closeChan != nil {
-			close(client.closeChan)
-		}
-
+		client.close()
 		client.Conn.Close()
-		close(client.Send)
 	}
 
 	// 清空映射
@@ -209,15 +338,31 @@ func (manager *Manager) cleanupAllConnections() {
 	manager.directBroadcast = make(map[uint]chan []byte)
 }
 
-// 移除指定客戶端
+// 移除指定客戶端，所有移除路徑都經由此處關閉客戶端
 func (manager *Manager) removeClient(client *Client) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
 	if _, ok := manager.clients[client]; !ok {
 		return
 	}
 
-	manager.mutex.Lock()
-	defer manager.mutex.Unlock()
+	manager.detachClientLocked(client)
+
+	// 停止心跳並關閉發送通道，不再接受新訊息；WritePump 會先送出佇列中剩餘的訊息再發送關閉幀
+	client.close()
 
+	// 排空逾時仍未結束時強制關閉連接
+	conn := client.Conn
+	time.AfterFunc(sendDrainTimeout, func() {
+		conn.Close()
+	})
+
+	log.Printf("Dealer WebSocket Manager: Client %s unregistered\n", client.ID)
+}
+
+// 從所有映射中移除客戶端，呼叫者需持有互斥鎖
+func (manager *Manager) detachClientLocked(client *Client) {
 	// 從用戶-客戶端映射中移除
 	if client.IsAuthed {
 		// 從 authClients 映射中移除
@@ -243,42 +388,50 @@ func (manager *Manager) removeClient(client *Client) {
 				delete(manager.userClients, client.UserID)
 			}
 		}
-	}
 
-	// 停止心跳
-	if client.heartbeatTicker != nil {
-		client.heartbeatTicker.Stop()
-	}
-
-	// 關閉信號通道
-	if client.closeChan != nil {
-		close(client.closeChan)
+		// 主要荷官離線時由最早認證的重複連接接替
+		if !client.DuplicateDealer {
+			manager.promoteDuplicateDealerLocked()
+		}
 	}
 
-	// 關閉連接
-	client.Conn.Close()
-
-	// 關閉發送通道
-	close(client.Send)
-
 	// 從客戶端列表中刪除
 	delete(manager.clients, client)
+}
 
-	log.Printf("Dealer WebSocket Manager: Client %s unregistered\n", client.ID)
+// 已無主要荷官連接時，清除最早認證的重複連接的標記，呼叫者需持有互斥鎖
+func (manager *Manager) promoteDuplicateDealerLocked() {
+	var next *Client
+	for _, clients := range manager.authClients {
+		for _, c := range clients {
+			if !c.DuplicateDealer {
+				return
+			}
+			if next == nil || c.authedAt.Before(next.authedAt) {
+				next = c
+			}
+		}
+	}
+
+	if next != nil {
+		next.DuplicateDealer = false
+		log.Printf("Dealer WebSocket Manager: Client %s (user %d) is now the active dealer connection\n", next.ID, next.UserID)
+	}
 }
 
-// 廣播消息到所有客戶端
-func (manager *Manager) broadcastMessage(message []byte) {
+// 廣播消息到所有已認證的客戶端，exclude 不為空時跳過該客戶端
+func (manager *Manager) broadcastMessage(message []byte, exclude *Client) {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
 
 	failedClients := make([]*Client, 0)
 
 	for client := range manager.clients {
-		select {
-		case client.Send <- message:
-			// 消息已送入通道
-		default:
+		// 未認證的連接不接收遊戲廣播
+		if !client.IsAuthed || client == exclude {
+			continue
+		}
+		if !client.trySend(message) {
 			// 發送通道已滿或已關閉，記錄待移除的客戶端
 			failedClients = append(failedClients, client)
 		}
@@ -287,60 +440,12 @@ func (manager *Manager) broadcastMessage(message []byte) {
 	// 如果有發送失敗的客戶端，解鎖後移除它們
 	if len(failedClients) > 0 {
 		manager.mutex.RUnlock()
-		manager.mutex.Lock()
 
 		for _, client := range failedClients {
 			log.Printf("Dealer WebSocket Manager: Removing client %s due to full send buffer", client.ID)
-
-			// 停止心跳
-			if client.heartbeatTicker != nil {
-				client.heartbeatTicker.Stop()
-			}
-
-			// 關閉信號通道
-			if client.closeChan != nil {
-				close(client.closeChan)
-			}
-
-			// 關閉連接
-			client.Conn.Close()
-
-			// 從用戶映射中移除
-			if client.IsAuthed {
-				// 從 authClients 映射中移除
-				clients := manager.authClients[client.UserID]
-				for i, c := range clients {
-					if c == client {
-						// 從切片中移除元素
-						clients = append(clients[:i], clients[i+1:]...)
-						break
-					}
-				}
-
-				// 更新或刪除映射
-				if len(clients) == 0 {
-					delete(manager.authClients, client.UserID)
-				} else {
-					manager.authClients[client.UserID] = clients
-				}
-
-				// 從 userClients 映射中移除
-				if clientsMap, exists := manager.userClients[client.UserID]; exists {
-					delete(clientsMap, client.ID)
-					if len(clientsMap) == 0 {
-						delete(manager.userClients, client.UserID)
-					}
-				}
-			}
-
-			// 關閉發送通道
-			close(client.Send)
-
-			// 從客戶端列表中刪除
-			delete(manager.clients, client)
+			manager.removeClient(client)
 		}
 
-		manager.mutex.Unlock()
 		manager.mutex.RLock()
 	}
 }
@@ -359,51 +464,9 @@ func (manager *Manager) cleanupInactiveConnections() {
 			inactiveCount++
 			log.Printf("Dealer WebSocket Manager: Client %s inactive for too long, closing connection", client.ID)
 
-			// 停止心跳
-			if client.heartbeatTicker != nil {
-				client.heartbeatTicker.Stop()
-			}
-
-			// 關閉信號通道
-			if client.closeChan != nil {
-				close(client.closeChan)
-			}
-
-			// 關閉連接
+			manager.detachClientLocked(client)
+			client.close()
 			client.Conn.Close()
-
-			// 從用戶映射中移除
-			if client.IsAuthed {
-				// 從 authClients 映射中移除
-				clients := manager.authClients[client.UserID]
-				for i, c := range clients {
-					if c == client {
-						// 從切片中移除元素
-						clients = append(clients[:i], clients[i+1:]...)
-						break
-					}
-				}
-				// 更新或刪除映射
-				if len(clients) == 0 {
-					delete(manager.authClients, client.UserID)
-				} else {
-					manager.authClients[client.UserID] = clients
-				}
-
-				// 從 userClients 映射中移除
-				if clientsMap, exists := manager.userClients[client.UserID]; exists {
-					delete(clientsMap, client.ID)
-					if len(clientsMap) == 0 {
-						delete(manager.userClients, client.UserID)
-					}
-				}
-			}
-
-			// 關閉發送通道
-			close(client.Send)
-
-			// 從客戶端列表中刪除
-			delete(manager.clients, client)
 		}
 	}
 
@@ -422,8 +485,19 @@ func (manager *Manager) AuthenticateClient(client *Client, token string) error {
 	manager.mutex.Lock()
 	defer manager.mutex.Unlock()
 
+	// 同一遊戲只應由一個荷官驅動，偵測到第二個荷官連接時記錄或拒絕
+	if active := manager.activeDealersLocked(); len(active) > 0 {
+		log.Printf("Dealer WebSocket Manager: Client %s (user %d) authenticated while %d dealer connection(s) already active: %v\n",
+			client.ID, userID, len(active), active)
+		if manager.rejectDuplicateDealers {
+			return ErrDuplicateDealer
+		}
+		client.DuplicateDealer = true
+	}
+
 	client.UserID = userID
 	client.IsAuthed = true
+	client.authedAt = time.Now()
 
 	// 將客戶端添加到用戶-客戶端映射
 	if _, exists := manager.userClients[userID]; !exists {
@@ -438,15 +512,244 @@ func (manager *Manager) AuthenticateClient(client *Client, token string) error {
 	return nil
 }
 
+// 設置業務消息處理器
+func (manager *Manager) SetMessageHandler(handler MessageHandler) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.messageHandler = handler
+}
+
+// 獲取業務消息處理器
+func (manager *Manager) getMessageHandler() MessageHandler {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	return manager.messageHandler
+}
+
+// 設置是否拒絕第二個荷官連接
+func (manager *Manager) SetRejectDuplicateDealers(reject bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.rejectDuplicateDealers = reject
+}
+
+// 獲取目前已認證的荷官連接ID，呼叫者需持有互斥鎖
+func (manager *Manager) activeDealersLocked() []string {
+	active := make([]string, 0)
+	for _, clients := range manager.authClients {
+		for _, c := range clients {
+			active = append(active, c.ID)
+		}
+	}
+	return active
+}
+
+// 獲取被標記為重複的荷官連接數量
+func (manager *Manager) GetDuplicateDealerCount() int {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	count := 0
+	for _, clients := range manager.authClients {
+		for _, c := range clients {
+			if c.DuplicateDealer {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// 檢查客戶端是否已認證
+func (client *Client) isAuthenticated() bool {
+	client.manager.mutex.RLock()
+	defer client.manager.mutex.RUnlock()
+
+	return client.IsAuthed
+}
+
+// 處理認證訊息，token 可放在頂層或 data 內
+func (client *Client) handleAuthentication(raw []byte) {
+	var authReq struct {
+		Token string      `json:"token"`
+		Data  AuthMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &authReq); err != nil {
+		client.sendMessage(NewAuthFailureMessage("invalid authentication message"))
+		return
+	}
+
+	token := authReq.Data.Token
+	if token == "" {
+		token = authReq.Token
+	}
+	if token == "" {
+		client.sendMessage(NewAuthFailureMessage("token is required"))
+		return
+	}
+
+	if client.isAuthenticated() {
+		client.sendMessage(NewAuthSuccessMessage(client.UserID))
+		return
+	}
+
+	if err := client.manager.AuthenticateClient(client, token); err != nil {
+		log.Printf("Dealer WebSocket Manager: Client %s authentication failed: %v\n", client.ID, err)
+		client.sendMessage(NewAuthFailureMessage(err.Error()))
+		return
+	}
+
+	client.sendMessage(NewAuthSuccessMessage(client.UserID))
+}
+
+// 認證逾時仍未完成則斷開連接
+func (client *Client) enforceAuthTimeout(timeout time.Duration) {
+	time.AfterFunc(timeout, func() {
+		if client.isAuthenticated() {
+			return
+		}
+
+		log.Printf("Dealer WebSocket Manager: Client %s did not authenticate within %v, closing connection\n", client.ID, timeout)
+
+		client.connMutex.Lock()
+		closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "authentication timeout")
+		_ = client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(client.manager.config.WriteTimeout))
+		client.connMutex.Unlock()
+
+		client.Conn.Close()
+	})
+}
+
+// 非阻塞地將訊息放入發送通道，通道已滿或已關閉時返回 false
+func (client *Client) trySend(message []byte) bool {
+	client.sendMutex.Lock()
+	defer client.sendMutex.Unlock()
+
+	if client.sendClosed {
+		return false
+	}
+
+	select {
+	case client.Send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// 停止心跳並關閉信號與發送通道，可安全地重複調用
+func (client *Client) close() {
+	client.closeWithReason("")
+}
+
+// 以指定的關閉原因關閉客戶端，WritePump 送出佇列中的訊息後以此原因發送關閉幀；
+// 已關閉的客戶端不會改變原因
+func (client *Client) closeWithReason(reason string) {
+	client.closeOnce.Do(func() {
+		client.closeReason = reason
+
+		if client.heartbeatTicker != nil {
+			client.heartbeatTicker.Stop()
+		}
+
+		if client.closeChan != nil {
+			close(client.closeChan)
+		}
+
+		client.sendMutex.Lock()
+		client.sendClosed = true
+		close(client.Send)
+		client.sendMutex.Unlock()
+	})
+}
+
+// 直接發送消息給當前客戶端，不進行廣播
+func (client *Client) sendMessage(message *BasicMessage) {
+	msgBytes, err := message.ToJSON()
+	if err != nil {
+		log.Printf("Dealer WebSocket Manager: Error marshaling message for client %s: %v\n", client.ID, err)
+		return
+	}
+
+	if !client.trySend(msgBytes) {
+		log.Printf("Dealer WebSocket Manager: Client %s send channel full, dropping %s message\n", client.ID, message.Type)
+	}
+}
+
+// 獲取已註冊的客戶端數量
+func (manager *Manager) GetClientCount() int {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	return len(manager.clients)
+}
+
+// 獲取已認證的客戶端數量
+func (manager *Manager) GetAuthenticatedClientCount() int {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	count := 0
+	for _, clients := range manager.authClients {
+		count += len(clients)
+	}
+	return count
+}
+
+// 獲取廣播通道中等待處理的消息數量
+func (manager *Manager) GetBroadcastQueueDepth() int {
+	return len(manager.broadcast)
+}
+
+// 獲取廣播通道容量
+func (manager *Manager) GetBroadcastQueueCapacity() int {
+	return cap(manager.broadcast)
+}
+
 // 廣播訊息給所有已認證的客戶端
 func (manager *Manager) BroadcastToAll(message interface{}) error {
+	return manager.broadcastExcept(nil, message)
+}
+
+// 廣播訊息給除 exclude 之外所有已認證的客戶端，用於請求者已直接收到回應的情況
+func (manager *Manager) BroadcastToOthers(exclude *Client, message interface{}) error {
+	return manager.broadcastExcept(exclude, message)
+}
+
+// 將廣播放入通道，exclude 不為空時跳過該客戶端
+func (manager *Manager) broadcastExcept(exclude *Client, message interface{}) error {
 	msgBytes, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
+	outbound := &outboundMessage{data: msgBytes, exclude: exclude}
 
-	manager.broadcast <- msgBytes
-	return nil
+	// 通道已滿時最多等待一小段時間，避免呼叫方因廣播循環停滯而阻塞
+	select {
+	case manager.broadcast <- outbound:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(broadcastEnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case manager.broadcast <- outbound:
+		return nil
+	case <-timer.C:
+		dropped := atomic.AddInt64(&manager.droppedBroadcasts, 1)
+		log.Printf("Dealer WebSocket Manager: Broadcast queue full, dropping message (total dropped: %d)", dropped)
+		return ErrBroadcastQueueFull
+	}
+}
+
+// 獲取因通道已滿而丟棄的廣播數量
+func (manager *Manager) GetDroppedBroadcastCount() int64 {
+	return atomic.LoadInt64(&manager.droppedBroadcasts)
 }
 
 // 向指定用戶發送訊息
@@ -467,33 +770,12 @@ func (manager *Manager) SendToUser(userID uint, message interface{}) error {
 
 	// 發送訊息給用戶的所有客戶端
 	for _, client := range clientMap {
-		if client.IsAuthed {
-			select {
-			case client.Send <- msgBytes:
-				// 訊息已送入通道
-			default:
-				// 發送通道已滿或已關閉，移除客戶端
-				client.connMutex.Lock()
-				if client.heartbeatTicker != nil {
-					client.heartbeatTicker.Stop()
-				}
-				if client.closeChan != nil {
-					close(client.closeChan)
-				}
-				client.Conn.Close()
-				client.connMutex.Unlock()
-
-				// 移除用戶客戶端映射
-				delete(manager.userClients[userID], client.ID)
-				if len(manager.userClients[userID]) == 0 {
-					delete(manager.userClients, userID)
-				}
-
-				// 移除客戶端
-				close(client.Send)
-				delete(manager.clients, client)
-				log.Printf("Dealer WebSocket Manager: Client %s removed (failed to send to user)\n", client.ID)
-			}
+		if client.IsAuthed && !client.trySend(msgBytes) {
+			// 發送通道已滿或已關閉，交由事件循環統一移除客戶端
+			log.Printf("Dealer WebSocket Manager: Client %s removed (failed to send to user)\n", client.ID)
+			go func(c *Client) {
+				manager.unregister <- c
+			}(client)
 		}
 	}
 
@@ -517,15 +799,16 @@ func (client *Client) ReadPump() {
 	}()
 
 	// 設置讀取參數
-	client.Conn.SetReadLimit(4096) // 4KB
-	client.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+	config := client.manager.config
+	client.Conn.SetReadLimit(config.ReadLimit)
+	client.Conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 
 	// 設置Pong處理器，更新最後活動時間
 	client.Conn.SetPongHandler(func(string) error {
 		client.connMutex.Lock()
 		defer client.connMutex.Unlock()
 
-		client.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+		client.Conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 		client.LastActivity = time.Now()
 		return nil
 	})
@@ -557,7 +840,7 @@ func (client *Client) ReadPump() {
 
 			client.connMutex.Lock()
 			client.LastActivity = time.Now()
-			client.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+			client.Conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 			client.connMutex.Unlock()
 
 			// 處理接收到的訊息
@@ -577,15 +860,25 @@ func (client *Client) ReadPump() {
 				}
 				responseBytes, _ := json.Marshal(heartbeatResponse)
 
-				select {
-				case client.Send <- responseBytes:
-					// 心跳已送入通道
-				default:
+				if !client.trySend(responseBytes) {
 					log.Printf("Dealer WebSocket Manager: Client %s send channel full for heartbeat\n", client.ID)
 				}
 				continue
 			}
 
+			// 處理認證訊息
+			if msg.Type == MessageTypeAuthentication {
+				client.handleAuthentication(message)
+				continue
+			}
+
+			// 未認證的客戶端只允許發送心跳與認證訊息
+			if !client.isAuthenticated() {
+				log.Printf("Dealer WebSocket Manager: Client %s sent %s before authentication\n", client.ID, msg.Type)
+				client.sendMessage(NewErrorMessage(401, "authentication required"))
+				continue
+			}
+
 			// 處理基準測試訊息
 			if msg.Type == "benchmark" {
 				// 確保我們返回一個與發送格式相同的消息
@@ -606,17 +899,20 @@ func (client *Client) ReadPump() {
 				}
 
 				// 發送回客戶端
-				select {
-				case client.Send <- responseBytes:
-					// 成功發送
+				if client.trySend(responseBytes) {
 					log.Printf("Dealer WebSocket Manager: Processed benchmark message from client %s\n", client.ID)
-				default:
+				} else {
 					log.Printf("Dealer WebSocket Manager: Failed to send benchmark response, buffer full for client %s\n", client.ID)
 				}
 				continue
 			}
 
-			// 處理其他訊息...
+			// 其他訊息交由業務處理器處理
+			if handler := client.manager.getMessageHandler(); handler != nil {
+				handler.HandleMessage(client, msg.Type, msg.Data)
+				continue
+			}
+
 			log.Printf("Dealer WebSocket Manager: Received message from client %s: %s\n", client.ID, message)
 		}
 	}
@@ -630,6 +926,9 @@ func (client *Client) WritePump() {
 		}
 		log.Printf("Dealer WebSocket Manager: Client %s WritePump exiting\n", client.ID)
 		client.Conn.Close()
+		if client.writeDone != nil {
+			close(client.writeDone)
+		}
 	}()
 
 	// 確保 closeChan 已初始化
@@ -641,13 +940,14 @@ func (client *Client) WritePump() {
 		select {
 		case <-client.closeChan:
 			log.Printf("Dealer WebSocket Manager: Client %s writer received close signal\n", client.ID)
+			client.drainSend(time.Now().Add(sendDrainTimeout))
 			return
 		case message, ok := <-client.Send:
 			if !ok {
 				// 通道已關閉
 				log.Printf("Dealer WebSocket Manager: Client %s send channel closed\n", client.ID)
 				client.connMutex.Lock()
-				err := client.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				err := client.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, client.closeReason))
 				client.connMutex.Unlock()
 				if err != nil {
 					log.Printf("Dealer WebSocket Manager: Client %s error sending close message: %v\n", client.ID, err)
@@ -663,7 +963,7 @@ func (client *Client) WritePump() {
 				return
 			}
 
-			client.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			client.Conn.SetWriteDeadline(time.Now().Add(client.manager.config.WriteTimeout))
 			w, err := client.Conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				client.connMutex.Unlock()
@@ -709,6 +1009,46 @@ func (client *Client) WritePump() {
 	}
 }
 
+// 送出發送通道中剩餘的訊息後發送關閉幀，超過 deadline 則放棄剩餘訊息
+func (client *Client) drainSend(deadline time.Time) {
+	client.connMutex.Lock()
+	defer client.connMutex.Unlock()
+
+	if client.Conn == nil {
+		return
+	}
+
+	flushed := 0
+	for time.Now().Before(deadline) {
+		var message []byte
+		var ok bool
+		select {
+		case message, ok = <-client.Send:
+		default:
+			// 佇列已空
+		}
+		if !ok {
+			break
+		}
+
+		client.Conn.SetWriteDeadline(deadline)
+		if err := client.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("Dealer WebSocket Manager: Client %s error flushing message: %v\n", client.ID, err)
+			return
+		}
+		flushed++
+	}
+
+	if flushed > 0 {
+		log.Printf("Dealer WebSocket Manager: Client %s flushed %d pending messages before close\n", client.ID, flushed)
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, client.closeReason)
+	if err := client.Conn.WriteControl(websocket.CloseMessage, closeMsg, deadline); err != nil {
+		log.Printf("Dealer WebSocket Manager: Client %s error sending close message: %v\n", client.ID, err)
+	}
+}
+
 // 開始心跳
 func (client *Client) StartHeartbeat() {
 	client.connMutex.Lock()
@@ -721,7 +1061,7 @@ func (client *Client) StartHeartbeat() {
 	}
 
 	// 創建新的心跳計時器
-	client.heartbeatTicker = time.NewTicker(heartbeatInterval)
+	client.heartbeatTicker = time.NewTicker(client.manager.config.PingInterval)
 	log.Printf("Dealer WebSocket Manager: Started heartbeat for client %s\n", client.ID)
 
 	// 創建本地副本
@@ -764,7 +1104,7 @@ func (client *Client) sendPing() error {
 	}
 
 	// 發送Ping訊息
-	if err := client.Conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(writeTimeout)); err != nil {
+	if err := client.Conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(client.manager.config.WriteTimeout)); err != nil {
 		log.Printf("Dealer WebSocket Manager: Client %s ping error: %v\n", client.ID, err)
 
 		// 直接調用 attemptReconnect (已在 goroutine 中)
@@ -781,11 +1121,8 @@ func (client *Client) sendPing() error {
 	}
 	heartbeatBytes, _ := json.Marshal(heartbeat)
 
-	select {
-	case client.Send <- heartbeatBytes:
-		// 心跳已送入通道
-	default:
-		// 發送通道已滿，可能需要處理
+	if !client.trySend(heartbeatBytes) {
+		// 發送通道已滿或已關閉
 		log.Printf("Dealer WebSocket Manager: Client %s send channel full, cannot send heartbeat\n", client.ID)
 	}
 
@@ -825,29 +1162,33 @@ func (client *Client) attemptReconnect() {
 
 // 關閉連接
 func (manager *Manager) Shutdown() {
+	manager.ShutdownWithTimeout(time.Second)
+}
+
+// 關閉連接，timeout 為通知所有客戶端關閉的總時限
+func (manager *Manager) ShutdownWithTimeout(timeout time.Duration) {
 	log.Println("Dealer WebSocket Manager: Shutdown initiated, closing all connections...")
 
-	// 通知所有客戶端關閉
+	deadline := time.Now().Add(timeout)
+
+	// 計畫內關閉通知，讓客戶端與異常斷線區分
+	notice, err := NewServerShutdownMessage("Server shutting down").ToJSON()
+	if err != nil {
+		log.Printf("Dealer WebSocket Manager: Failed to build shutdown notice: %v", err)
+	}
+
+	// 通知所有客戶端關閉：通知排在佇列最後，由 WritePump 依序送出佇列、通知與關閉幀
 	manager.mutex.Lock()
-	clientCount := len(manager.clients)
+	clients := make([]*Client, 0, len(manager.clients))
 
 	for client := range manager.clients {
 		log.Printf("Dealer WebSocket Manager: Closing connection for client %s", client.ID)
 
-		if client.heartbeatTicker != nil {
-			client.heartbeatTicker.Stop()
-		}
-
-		if client.closeChan != nil {
-			close(client.closeChan)
+		if notice != nil && !client.trySend(notice) {
+			log.Printf("Dealer WebSocket Manager: Client %s send buffer full, shutdown notice not queued", client.ID)
 		}
-
-		// 向客戶端發送關閉消息
-		closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Server shutting down")
-		_ = client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
-
-		client.Conn.Close()
-		close(client.Send)
+		client.closeWithReason("Server shutting down")
+		clients = append(clients, client)
 	}
 
 	// 清空客戶端映射
@@ -856,6 +1197,23 @@ func (manager *Manager) Shutdown() {
 	manager.userClients = make(map[uint]map[string]*Client)
 	manager.mutex.Unlock()
 
+	// 等待各連接送出關閉幀，超過時限則強制關閉
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	waiting := true
+	for _, client := range clients {
+		if waiting && client.writeDone != nil {
+			select {
+			case <-client.writeDone:
+			case <-timer.C:
+				log.Println("Dealer WebSocket Manager: Shutdown timed out waiting for pending messages")
+				waiting = false
+			}
+		}
+		client.Conn.Close()
+	}
+	clientCount := len(clients)
+
 	// 關閉管理器的shutdown通道
 	close(manager.shutdown)
 
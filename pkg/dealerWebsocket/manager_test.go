@@ -0,0 +1,486 @@
+package dealerWebsocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testTokens 測試用的令牌與用戶ID
+var testTokens = map[string]uint{
+	"dealer-1": 1,
+	"dealer-2": 2,
+}
+
+func testAuth(token string) (uint, error) {
+	if userID, ok := testTokens[token]; ok {
+		return userID, nil
+	}
+	return 0, errors.New("invalid token")
+}
+
+// testMessage 測試時解析收到的消息，Data 延後解析；Seq 僅測試廣播使用
+type testMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	Seq  int             `json:"seq"`
+}
+
+// testConn 測試用的連接，保留同一幀中尚未讀取的消息
+type testConn struct {
+	*websocket.Conn
+	pending [][]byte
+}
+
+// testBroadcast 測試用的廣播內容
+type testBroadcast struct {
+	Type string `json:"type"`
+	Seq  int    `json:"seq"`
+}
+
+// startDealerServer 啟動管理器與荷官端 HTTP 測試服務器
+func startDealerServer(t *testing.T) (*Manager, *WebSocketHandler, *httptest.Server) {
+	t.Helper()
+
+	manager := NewManager(testAuth)
+	handler := NewWebSocketHandler(manager, testAuth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go manager.Start(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+	})
+	return manager, handler, server
+}
+
+// dialDealer 連接荷官端測試服務器
+func dialDealer(t *testing.T, server *httptest.Server) *testConn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{Conn: conn}
+}
+
+// readMessage 讀取指定類型的消息
+func readMessage(t *testing.T, conn *testConn, messageType string) testMessage {
+	t.Helper()
+
+	messages := readMessagesUntil(t, conn, messageType)
+	return messages[len(messages)-1]
+}
+
+// readMessagesUntil 讀取消息直到收到指定類型，返回途中收到的所有消息；
+// 一個幀中可能合併多條以換行分隔的消息，未讀取的部分留待下次讀取
+func readMessagesUntil(t *testing.T, conn *testConn, messageType string) []testMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var messages []testMessage
+	for {
+		if len(conn.pending) == 0 {
+			_, frame, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("read %s: %v", messageType, err)
+			}
+			conn.pending = bytes.Split(frame, []byte{'\n'})
+		}
+
+		line := conn.pending[0]
+		conn.pending = conn.pending[1:]
+
+		var msg testMessage
+		if json.Unmarshal(line, &msg) != nil {
+			continue
+		}
+		messages = append(messages, msg)
+		if msg.Type == messageType {
+			return messages
+		}
+	}
+}
+
+// sendJSON 向服務器發送一條 JSON 消息
+func sendJSON(t *testing.T, conn *testConn, message interface{}) {
+	t.Helper()
+
+	if err := conn.WriteJSON(message); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// authenticate 以令牌完成認證，返回認證成功前收到的消息
+func authenticate(t *testing.T, conn *testConn, token string) []testMessage {
+	t.Helper()
+
+	sendJSON(t, conn, map[string]interface{}{
+		"type": MessageTypeAuthentication,
+		"data": AuthMessage{Token: token},
+	})
+	messages := readMessagesUntil(t, conn, MessageTypeAuthSuccess)
+	return messages[:len(messages)-1]
+}
+
+// waitForClients 等待管理器註冊指定數量的客戶端
+func waitForClients(t *testing.T, manager *Manager, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.GetClientCount() != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("client count = %d, want %d", manager.GetClientCount(), count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// broadcastSeq 廣播帶序號的測試消息
+func broadcastSeq(t *testing.T, manager *Manager, seq int) {
+	t.Helper()
+
+	if err := manager.BroadcastToAll(testBroadcast{Type: "test_broadcast", Seq: seq}); err != nil {
+		t.Fatalf("BroadcastToAll: %v", err)
+	}
+}
+
+// readBroadcastSeq 讀取下一條測試廣播的序號
+func readBroadcastSeq(t *testing.T, conn *testConn) int {
+	t.Helper()
+
+	return readMessage(t, conn, "test_broadcast").Seq
+}
+
+func TestUnauthenticatedMessageRejected(t *testing.T) {
+	_, _, server := startDealerServer(t)
+	conn := dialDealer(t, server)
+
+	sendJSON(t, conn, map[string]string{"type": "start_game"})
+
+	var payload ErrorMessage
+	if err := json.Unmarshal(readMessage(t, conn, MessageTypeError).Data, &payload); err != nil {
+		t.Fatalf("decode error message: %v", err)
+	}
+	if payload.Code != 401 || payload.Message != "authentication required" {
+		t.Fatalf("error = %+v, want 401 authentication required", payload)
+	}
+}
+
+func TestAuthenticationFailure(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	conn := dialDealer(t, server)
+
+	sendJSON(t, conn, map[string]interface{}{
+		"type": MessageTypeAuthentication,
+		"data": AuthMessage{Token: "wrong"},
+	})
+
+	var payload AuthResponseMessage
+	if err := json.Unmarshal(readMessage(t, conn, MessageTypeAuthFailure).Data, &payload); err != nil {
+		t.Fatalf("decode auth failure: %v", err)
+	}
+	if payload.Success {
+		t.Fatal("auth failure reported success")
+	}
+	if count := manager.GetAuthenticatedClientCount(); count != 0 {
+		t.Fatalf("authenticated clients = %d after failed auth, want 0", count)
+	}
+}
+
+func TestBroadcastSkipsUnauthenticatedClients(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+
+	authed := dialDealer(t, server)
+	pending := dialDealer(t, server)
+	waitForClients(t, manager, 2)
+	authenticate(t, authed, "dealer-1")
+
+	broadcastSeq(t, manager, 1)
+	if seq := readBroadcastSeq(t, authed); seq != 1 {
+		t.Fatalf("authenticated client got broadcast %d, want 1", seq)
+	}
+
+	// 認證前的廣播不會送達；若錯誤送達，會排在認證成功回應之前
+	for _, msg := range authenticate(t, pending, "dealer-2") {
+		if msg.Type == "test_broadcast" {
+			t.Fatal("unauthenticated client received a broadcast")
+		}
+	}
+	broadcastSeq(t, manager, 2)
+	if seq := readBroadcastSeq(t, pending); seq != 2 {
+		t.Fatalf("client got broadcast %d after authenticating, want 2", seq)
+	}
+}
+
+func TestDuplicateDealerPromotedWhenActiveDealerLeaves(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+
+	active := dialDealer(t, server)
+	second := dialDealer(t, server)
+	third := dialDealer(t, server)
+	authenticate(t, active, "dealer-1")
+	authenticate(t, second, "dealer-2")
+	authenticate(t, third, "dealer-2")
+
+	if count := manager.GetDuplicateDealerCount(); count != 2 {
+		t.Fatalf("duplicate dealers = %d, want 2", count)
+	}
+
+	// 主要荷官離線後，最早認證的重複連接接替，不再計為重複
+	active.Close()
+	waitForClients(t, manager, 2)
+	if count := manager.GetDuplicateDealerCount(); count != 1 {
+		t.Fatalf("duplicate dealers after active dealer left = %d, want 1", count)
+	}
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	clients := manager.authClients[2]
+	if len(clients) != 2 {
+		t.Fatalf("user 2 has %d connections, want 2", len(clients))
+	}
+	earlier, later := clients[0], clients[1]
+	if later.authedAt.Before(earlier.authedAt) {
+		earlier, later = later, earlier
+	}
+	if earlier.DuplicateDealer || !later.DuplicateDealer {
+		t.Fatalf("duplicate flags = %v, %v, want the earlier connection promoted", earlier.DuplicateDealer, later.DuplicateDealer)
+	}
+}
+
+func TestDuplicateDealerLeavingKeepsActiveDealer(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+
+	active := dialDealer(t, server)
+	duplicate := dialDealer(t, server)
+	authenticate(t, active, "dealer-1")
+	authenticate(t, duplicate, "dealer-2")
+
+	duplicate.Close()
+	waitForClients(t, manager, 1)
+	if count := manager.GetDuplicateDealerCount(); count != 0 {
+		t.Fatalf("duplicate dealers = %d, want 0", count)
+	}
+	if count := manager.GetAuthenticatedClientCount(); count != 1 {
+		t.Fatalf("authenticated clients = %d, want 1", count)
+	}
+}
+
+func TestRejectedDuplicateDealerCanAuthenticateAfterActiveLeaves(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	manager.SetRejectDuplicateDealers(true)
+
+	active := dialDealer(t, server)
+	second := dialDealer(t, server)
+	authenticate(t, active, "dealer-1")
+
+	sendJSON(t, second, map[string]interface{}{
+		"type": MessageTypeAuthentication,
+		"data": AuthMessage{Token: "dealer-2"},
+	})
+	var payload AuthResponseMessage
+	if err := json.Unmarshal(readMessage(t, second, MessageTypeAuthFailure).Data, &payload); err != nil {
+		t.Fatalf("decode auth failure: %v", err)
+	}
+	if payload.Message != ErrDuplicateDealer.Error() {
+		t.Fatalf("auth failure = %q, want %q", payload.Message, ErrDuplicateDealer)
+	}
+
+	active.Close()
+	waitForClients(t, manager, 1)
+	authenticate(t, second, "dealer-2")
+}
+
+// serverClient 返回管理器中唯一註冊的客戶端
+func serverClient(t *testing.T, manager *Manager) *Client {
+	t.Helper()
+
+	waitForClients(t, manager, 1)
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	for client := range manager.clients {
+		return client
+	}
+	return nil
+}
+
+func TestRemoveClientFlushesQueuedMessagesBeforeClose(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	conn := dialDealer(t, server)
+	authenticate(t, conn, "dealer-1")
+	client := serverClient(t, manager)
+
+	const queued = 20
+	for seq := 1; seq <= queued; seq++ {
+		data, _ := json.Marshal(testBroadcast{Type: "test_broadcast", Seq: seq})
+		if !client.trySend(data) {
+			t.Fatalf("queue message %d: send buffer full", seq)
+		}
+	}
+	manager.removeClient(client)
+
+	// 佇列中的消息全部送達後才收到關閉幀；發送通道先關閉時關閉幀不帶狀態碼
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	next := 1
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				t.Fatalf("read error = %v, want a close frame", err)
+			}
+			break
+		}
+		for _, line := range bytes.Split(frame, []byte{'\n'}) {
+			var msg testMessage
+			if json.Unmarshal(line, &msg) != nil || msg.Type != "test_broadcast" {
+				continue
+			}
+			if msg.Seq != next {
+				t.Fatalf("got message %d, want %d", msg.Seq, next)
+			}
+			next++
+		}
+	}
+	if next != queued+1 {
+		t.Fatalf("received %d of %d queued messages before close", next-1, queued)
+	}
+}
+
+func TestBroadcastDropsStalledClient(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	stalled := dialDealer(t, server)
+	authenticate(t, stalled, "dealer-1")
+	stalledClient := serverClient(t, manager)
+
+	healthy := dialDealer(t, server)
+	authenticate(t, healthy, "dealer-2")
+
+	// 停滯的客戶端不讀取消息，持續排入大消息直到發送通道塞滿
+	payload := bytes.Repeat([]byte("x"), 64*1024)
+	deadline := time.Now().Add(5 * time.Second)
+	for stalledClient.trySend(payload) {
+		if time.Now().After(deadline) {
+			t.Fatal("send buffer of the stalled client never filled")
+		}
+	}
+
+	// 廣播不被停滯的客戶端阻塞，其他客戶端照常收到，停滯的客戶端被移除
+	start := time.Now()
+	broadcastSeq(t, manager, 1)
+	if seq := readBroadcastSeq(t, healthy); seq != 1 {
+		t.Fatalf("healthy client got broadcast %d, want 1", seq)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("broadcast reached the healthy client after %v", elapsed)
+	}
+	waitForClients(t, manager, 1)
+
+	broadcastSeq(t, manager, 2)
+	if seq := readBroadcastSeq(t, healthy); seq != 2 {
+		t.Fatalf("healthy client got broadcast %d after the stalled client was dropped, want 2", seq)
+	}
+}
+
+func TestShutdownSendsNoticeBeforeClose(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	conn := dialDealer(t, server)
+	authenticate(t, conn, "dealer-1")
+	client := serverClient(t, manager)
+
+	// 關閉前已排入佇列的消息先送出
+	const queued = 5
+	for seq := 1; seq <= queued; seq++ {
+		data, _ := json.Marshal(testBroadcast{Type: "test_broadcast", Seq: seq})
+		if !client.trySend(data) {
+			t.Fatalf("queue message %d: send buffer full", seq)
+		}
+	}
+	manager.ShutdownWithTimeout(2 * time.Second)
+
+	// 依序收到佇列中的消息、關閉通知，最後才是關閉幀
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var received []string
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "Server shutting down" {
+				t.Fatalf("read error = %v, want a normal close frame with the shutdown reason", err)
+			}
+			break
+		}
+		for _, line := range bytes.Split(frame, []byte{'\n'}) {
+			var msg testMessage
+			if json.Unmarshal(line, &msg) != nil {
+				continue
+			}
+			if msg.Type == "test_broadcast" {
+				received = append(received, fmt.Sprintf("seq %d", msg.Seq))
+			} else if msg.Type == MessageTypeServerShutdown {
+				received = append(received, msg.Type)
+			}
+		}
+	}
+
+	want := "[seq 1 seq 2 seq 3 seq 4 seq 5 " + MessageTypeServerShutdown + "]"
+	if got := fmt.Sprint(received); got != want {
+		t.Fatalf("messages before close = %s, want %s", got, want)
+	}
+}
+
+func TestConcurrentUnregisterDoesNotPanic(t *testing.T) {
+	manager, _, server := startDealerServer(t)
+	conn := dialDealer(t, server)
+	authenticate(t, conn, "dealer-1")
+	client := serverClient(t, manager)
+
+	// 同時從事件循環、直接移除與關閉三條路徑註銷同一客戶端
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			manager.unregister <- client
+		}()
+		go func() {
+			defer wg.Done()
+			manager.removeClient(client)
+		}()
+		go func() {
+			defer wg.Done()
+			client.close()
+		}()
+	}
+	wg.Wait()
+	waitForClients(t, manager, 0)
+
+	if client.trySend([]byte("late")) {
+		t.Fatal("trySend succeeded on a closed client")
+	}
+	if count := manager.GetAuthenticatedClientCount(); count != 0 {
+		t.Fatalf("authenticated clients = %d after unregister, want 0", count)
+	}
+
+	// 事件循環未因重複關閉而中止，仍可註冊新連接
+	dialDealer(t, server)
+	waitForClients(t, manager, 1)
+}
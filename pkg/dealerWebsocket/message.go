@@ -9,12 +9,13 @@ import (
 // 消息類型常量
 const (
 	// 系統消息類型
-	MessageTypeHeartbeat      = "heartbeat"      // 心跳消息
-	MessageTypeAuthentication = "authentication" // 認證消息
-	MessageTypeAuthSuccess    = "auth_success"   // 認證成功
-	MessageTypeAuthFailure    = "auth_failure"   // 認證失敗
-	MessageTypeSystemNotice   = "system_notice"  // 系統通知
-	MessageTypeError          = "error"          // 錯誤消息
+	MessageTypeHeartbeat      = "heartbeat"       // 心跳消息
+	MessageTypeAuthentication = "authentication"  // 認證消息
+	MessageTypeAuthSuccess    = "auth_success"    // 認證成功
+	MessageTypeAuthFailure    = "auth_failure"    // 認證失敗
+	MessageTypeSystemNotice   = "system_notice"   // 系統通知
+	MessageTypeError          = "error"           // 錯誤消息
+	MessageTypeServerShutdown = "server_shutdown" // 服務器計畫內關閉通知
 
 	// 業務消息類型
 	MessageTypeTicketPurchase = "ticket_purchase" // 票券購買消息
@@ -53,6 +54,11 @@ type ErrorMessage struct {
 	Message string `json:"message"` // 錯誤信息
 }
 
+// 服務器關閉通知消息
+type ServerShutdownMessage struct {
+	Reason string `json:"reason"` // 關閉原因
+}
+
 // 票券購買消息
 type TicketPurchaseMessage struct {
 	OrderID      string    `json:"order_id"`      // 訂單ID
@@ -132,6 +138,11 @@ func NewAuthFailureMessage(message string) *BasicMessage {
 	return NewMessage(MessageTypeAuthFailure, authResponse)
 }
 
+// 創建服務器關閉通知消息
+func NewServerShutdownMessage(reason string) *BasicMessage {
+	return NewMessage(MessageTypeServerShutdown, ServerShutdownMessage{Reason: reason})
+}
+
 // 創建錯誤消息
 func NewErrorMessage(code int, message string) *BasicMessage {
 	errorData := ErrorMessage{
@@ -1,12 +1,44 @@
 package middleware
 
 import (
+	"context"
 	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// RequestIDHeader 傳遞請求ID的 HTTP 標頭
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey 請求ID在 gin.Context 與 context.Context 中的鍵
+type requestIDKey struct{}
+
+// RequestID 讀取客戶端帶入的請求ID，未帶入時自動產生，並寫回回應標頭
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, requestID))
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// GetRequestID 從 context 取得請求ID，不存在時返回空字串
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
 // Logger 記錄請求信息的中間件
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -35,12 +67,13 @@ func Logger() gin.HandlerFunc {
 		clientIP := c.ClientIP()
 
 		// 日誌格式
-		log.Printf("| %3d | %13v | %15s | %s | %s |",
+		log.Printf("| %3d | %13v | %15s | %s | %s | %s |",
 			statusCode,
 			latency,
 			clientIP,
 			reqMethod,
 			reqUri,
+			GetRequestID(c.Request.Context()),
 		)
 	}
 }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// performRequest 經由 RequestID 中間件發送請求，返回回應與處理器從 context 取得的請求ID
+func performRequest(t *testing.T, requestID string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var seen string
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/ping", func(c *gin.Context) {
+		seen = GetRequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestRequestIDEchoesClientID(t *testing.T) {
+	w, seen := performRequest(t, "req-123")
+
+	if got := w.Header().Get(RequestIDHeader); got != "req-123" {
+		t.Fatalf("%s header = %q, want req-123", RequestIDHeader, got)
+	}
+	if seen != "req-123" {
+		t.Fatalf("request id in context = %q, want req-123", seen)
+	}
+}
+
+func TestRequestIDGeneratedWhenMissing(t *testing.T) {
+	w, seen := performRequest(t, "")
+
+	generated := w.Header().Get(RequestIDHeader)
+	if _, err := uuid.Parse(generated); err != nil {
+		t.Fatalf("%s header = %q, want a generated UUID", RequestIDHeader, generated)
+	}
+	if seen != generated {
+		t.Fatalf("request id in context = %q, want the generated %q", seen, generated)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"g38_lottery_service/internal/config"
+	"g38_lottery_service/pkg/utils"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
@@ -21,10 +22,11 @@ func IsKeyNotExist(err error) bool {
 
 // RedisConfig 存儲 Redis 連接的配置項
 type RedisConfig struct {
-	Addr     string
-	Username string
-	Password string
-	DB       int
+	Addr         string
+	Username     string
+	Password     string
+	DB           int
+	CloseTimeout time.Duration // 關閉連接的時限，0 表示不限制
 }
 
 // RedisManager 提供 Redis 操作的介面
@@ -117,7 +119,7 @@ func ProvideRedisClient(lc fx.Lifecycle, config *RedisConfig) *redis.Client {
 		},
 		OnStop: func(ctx context.Context) error {
 			fmt.Println("Closing Redis connection...")
-			return client.Close()
+			return utils.CloseWithTimeout("Redis connection", client.Close, config.CloseTimeout)
 		},
 	})
 
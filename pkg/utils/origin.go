@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// NewOriginChecker 創建 WebSocket 升級時的來源檢查函數。
+// allowedOrigins 為 nil 時不檢查；包含 "*" 時允許所有來源；
+// 其餘情況僅允許未帶 Origin 的非瀏覽器客戶端、同源請求與清單中的來源
+func NewOriginChecker(allowedOrigins []string) func(r *http.Request) bool {
+	if allowedOrigins == nil {
+		return func(r *http.Request) bool { return true }
+	}
+
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			return func(r *http.Request) bool { return true }
+		}
+		allowed[origin] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed[origin] {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return u.Host == r.Host
+	}
+}
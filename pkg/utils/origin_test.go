@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// checkOrigin 以指定的 Host 與 Origin 標頭執行來源檢查，origin 為空時不帶 Origin
+func checkOrigin(allowedOrigins []string, host, origin string) bool {
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Host = host
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return NewOriginChecker(allowedOrigins)(r)
+}
+
+func TestOriginCheckerAllowlist(t *testing.T) {
+	allowed := []string{"https://dealer.example.com", "https://admin.example.com"}
+
+	cases := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"listed origin", "https://dealer.example.com", true},
+		{"second listed origin", "https://admin.example.com", true},
+		{"unlisted origin", "https://evil.example.com", false},
+		{"different scheme", "http://dealer.example.com", false},
+		{"different port", "https://dealer.example.com:8443", false},
+		{"same origin as host", "https://api.example.com", true},
+		{"malformed origin", "://bad", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := checkOrigin(allowed, "api.example.com", tc.origin); got != tc.want {
+				t.Fatalf("origin %q allowed = %v, want %v", tc.origin, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOriginCheckerWildcard(t *testing.T) {
+	// 清單中包含 "*" 時其他項目不影響結果
+	for _, allowed := range [][]string{{"*"}, {"https://dealer.example.com", "*"}} {
+		if !checkOrigin(allowed, "api.example.com", "https://evil.example.com") {
+			t.Fatalf("origin rejected with allowed origins %v, want all origins allowed", allowed)
+		}
+	}
+}
+
+func TestOriginCheckerNilAllowsAll(t *testing.T) {
+	if !checkOrigin(nil, "api.example.com", "https://evil.example.com") {
+		t.Fatal("origin rejected without an allowlist, want no check")
+	}
+}
+
+func TestOriginCheckerMissingOrigin(t *testing.T) {
+	// 非瀏覽器客戶端不帶 Origin，即使清單為空也允許
+	for _, allowed := range [][]string{{"https://dealer.example.com"}, {}} {
+		if !checkOrigin(allowed, "api.example.com", "") {
+			t.Fatalf("request without Origin rejected with allowed origins %v, want allowed", allowed)
+		}
+	}
+
+	// 空清單仍拒絕瀏覽器的跨來源請求
+	if checkOrigin([]string{}, "api.example.com", "https://dealer.example.com") {
+		t.Fatal("cross-origin request allowed with an empty allowlist, want rejected")
+	}
+}
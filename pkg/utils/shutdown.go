@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// CloseWithTimeout 在時限內執行關閉，逾時則放棄等待並返回錯誤；timeout 不大於 0 時直接等待關閉完成。
+// name 用於錯誤訊息，例如 "MySQL connection"
+func CloseWithTimeout(name string, closeFn func() error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return closeFn()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- closeFn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("closing %s timed out after %v", name, timeout)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseWithTimeoutReturnsCloseError(t *testing.T) {
+	closeErr := errors.New("close failed")
+	if err := CloseWithTimeout("test connection", func() error { return closeErr }, time.Second); !errors.Is(err, closeErr) {
+		t.Fatalf("CloseWithTimeout error = %v, want %v", err, closeErr)
+	}
+}
+
+func TestCloseWithTimeoutGivesUpAfterTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	const timeout = 20 * time.Millisecond
+	started := time.Now()
+	err := CloseWithTimeout("test connection", func() error {
+		<-release
+		return nil
+	}, timeout)
+
+	if err == nil || !strings.Contains(err.Error(), "closing test connection timed out") {
+		t.Fatalf("CloseWithTimeout error = %v, want timeout error", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("CloseWithTimeout waited %v, want about %v", elapsed, timeout)
+	}
+}
+
+func TestCloseWithTimeoutWithoutLimit(t *testing.T) {
+	called := false
+	if err := CloseWithTimeout("test connection", func() error {
+		called = true
+		return nil
+	}, 0); err != nil || !called {
+		t.Fatalf("CloseWithTimeout = %v, called = %v, want nil and called", err, called)
+	}
+}
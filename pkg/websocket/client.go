@@ -1,9 +1,12 @@
 package websocket
 
 import (
+	"compress/flate"
+	"encoding/json"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -21,8 +24,92 @@ const (
 	maxMessageSize = 512
 )
 
+// Config 連接讀寫限制與超時設定，未設置的欄位使用預設值
+type Config struct {
+	// 最大消息大小
+	ReadLimit int64
+
+	// 讀取下一個 pong 消息的等待時間
+	ReadTimeout time.Duration
+
+	// 發送 ping 消息的頻率，應小於 ReadTimeout
+	PingInterval time.Duration
+
+	// 向客戶端寫入消息的等待時間
+	WriteTimeout time.Duration
+
+	// 是否與支援的客戶端協商 permessage-deflate 壓縮
+	EnableCompression bool
+
+	// 壓縮等級（flate 等級，-2 至 9），0 使用預設等級
+	CompressionLevel int
+
+	// 房間ID格式（正則表達式），空值使用 DefaultRoomIDPattern
+	RoomIDPattern string
+
+	// 斷線後保留房間訂閱以供同一客戶端ID重連恢復的時間
+	ResumeGracePeriod time.Duration
+
+	// 允許連接的來源，nil 表示不檢查，"*" 表示允許所有來源
+	AllowedOrigins []string
+
+	// 單一房間的訂閱上限，0 表示不限制
+	MaxSubscribersPerRoom int
+
+	// 所有房間的訂閱總數上限，0 表示不限制
+	MaxRoomSubscriptions int
+}
+
+// DefaultConfig 返回預設的連接讀寫設定
+func DefaultConfig() Config {
+	return Config{
+		ReadLimit:    maxMessageSize,
+		ReadTimeout:  pongWait,
+		PingInterval: pingPeriod,
+		WriteTimeout: writeWait,
+
+		CompressionLevel: flate.DefaultCompression,
+		RoomIDPattern:    DefaultRoomIDPattern,
+
+		ResumeGracePeriod: defaultResumeGracePeriod,
+	}
+}
+
+// withDefaults 以預設值補齊未設置的欄位
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.ReadLimit <= 0 {
+		c.ReadLimit = defaults.ReadLimit
+	}
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = defaults.ReadTimeout
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = defaults.PingInterval
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaults.WriteTimeout
+	}
+	if c.CompressionLevel == 0 {
+		c.CompressionLevel = defaults.CompressionLevel
+	}
+	if c.RoomIDPattern == "" {
+		c.RoomIDPattern = defaults.RoomIDPattern
+	}
+	if c.ResumeGracePeriod <= 0 {
+		c.ResumeGracePeriod = defaults.ResumeGracePeriod
+	}
+	return c
+}
+
 // Client 是 WebSocket 連接的中間人
 type Client struct {
+	// 客戶端ID，重連時可帶入以恢復房間訂閱
+	ID string
+
+	// 重連時客戶端帶入的前一個ID，註冊後清空
+	resumeID string
+
 	// WebSocket 連接
 	conn *websocket.Conn
 
@@ -31,14 +118,19 @@ type Client struct {
 
 	// 管理器
 	manager *Manager
+
+	// 已訂閱的房間，由管理器的互斥鎖保護
+	rooms map[string]bool
 }
 
 // NewClient 創建一個新的客戶端
 func NewClient(manager *Manager, conn *websocket.Conn) *Client {
 	return &Client{
+		ID:      uuid.New().String(),
 		conn:    conn,
 		send:    make(chan []byte, 256),
 		manager: manager,
+		rooms:   make(map[string]bool),
 	}
 }
 
@@ -49,10 +141,11 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(maxMessageSize)
-	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	config := c.manager.config
+	c.conn.SetReadLimit(config.ReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 		return nil
 	})
 
@@ -65,6 +158,14 @@ func (c *Client) readPump() {
 			break
 		}
 
+		// 處理房間訂閱命令
+		var cmd RoomCommand
+		if err := json.Unmarshal(message, &cmd); err == nil && cmd.Type != "" {
+			if c.handleRoomCommand(&cmd) {
+				continue
+			}
+		}
+
 		// 收到消息後，回覆一個 Hello World 消息
 		c.manager.broadcast <- []byte("Hello from server: " + string(message))
 	}
@@ -72,7 +173,8 @@ func (c *Client) readPump() {
 
 // writePump 將消息寫入 WebSocket 連接
 func (c *Client) writePump() {
-	ticker := time.NewTicker(pingPeriod)
+	config := c.manager.config
+	ticker := time.NewTicker(config.PingInterval)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -81,7 +183,7 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
 			if !ok {
 				// 管道關閉
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
@@ -105,7 +207,7 @@ func (c *Client) writePump() {
 				return
 			}
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
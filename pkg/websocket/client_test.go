@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// waitForClose 持續讀取直到連接被關閉，返回等待時間與讀取錯誤
+func waitForClose(t *testing.T, conn *websocket.Conn, timeout time.Duration) (time.Duration, error) {
+	t.Helper()
+
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(timeout))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if strings.Contains(err.Error(), "i/o timeout") {
+				t.Fatalf("connection still open after %v", timeout)
+			}
+			return time.Since(start), err
+		}
+	}
+}
+
+func TestReadLimitApplied(t *testing.T) {
+	_, server := startTestServer(t, Config{ReadLimit: 16})
+	player := dialTestServer(t, server, "")
+
+	if err := player.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 17))); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, err := waitForClose(t, player, 2*time.Second)
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("read error = %v, want close %d for a message over the read limit", err, websocket.CloseMessageTooBig)
+	}
+}
+
+func TestReadTimeoutApplied(t *testing.T) {
+	// ping 間隔大於讀取超時，客戶端不發送任何消息時服務器於讀取超時後斷線
+	_, server := startTestServer(t, Config{ReadTimeout: 200 * time.Millisecond, PingInterval: time.Hour})
+	player := dialTestServer(t, server, "")
+
+	if elapsed, _ := waitForClose(t, player, 5*time.Second); elapsed < 150*time.Millisecond {
+		t.Fatalf("connection closed after %v, want the 200ms read timeout", elapsed)
+	}
+}
+
+func TestPingIntervalApplied(t *testing.T) {
+	_, server := startTestServer(t, Config{PingInterval: 50 * time.Millisecond})
+	player := dialTestServer(t, server, "")
+
+	var pings atomic.Int32
+	player.SetPingHandler(func(string) error {
+		pings.Add(1)
+		return nil
+	})
+
+	// 讀取期間才會處理 ping，讀取超時後檢查收到的次數
+	player.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	for {
+		if _, _, err := player.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	// 預設間隔為 54 秒，期間內收到多次 ping 表示使用了設定的間隔
+	if count := pings.Load(); count < 3 {
+		t.Fatalf("received %d pings in 500ms, want at least 3 with a 50ms interval", count)
+	}
+}
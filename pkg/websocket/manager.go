@@ -4,18 +4,17 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"regexp"
 	"sync"
 
+	"g38_lottery_service/pkg/utils"
+
 	"github.com/gorilla/websocket"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	// 允許所有來源的連接，生產環境應該限制
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
 }
 
 // Manager 管理 WebSocket 連接
@@ -32,6 +31,21 @@ type Manager struct {
 	// 取消註冊請求
 	unregister chan *Client
 
+	// 房間訂閱者，房間ID -> 客戶端集合
+	rooms map[string]map[*Client]bool
+
+	// 房間廣播消息通道
+	roomBroadcast chan *roomMessage
+
+	// 連接讀寫限制與超時設定
+	config Config
+
+	// 房間ID格式
+	roomIDPattern *regexp.Regexp
+
+	// 已斷線客戶端保留的房間訂閱，客戶端ID -> 訂閱記錄
+	sessions map[string]*session
+
 	// 互斥鎖，保護資源
 	mutex sync.Mutex
 }
@@ -39,11 +53,32 @@ type Manager struct {
 // NewManager 創建一個新的管理器
 func NewManager() *Manager {
 	return &Manager{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:       make(map[*Client]bool),
+		broadcast:     make(chan []byte),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		rooms:         make(map[string]map[*Client]bool),
+		roomBroadcast: make(chan *roomMessage, roomBroadcastBuffer),
+		config:        DefaultConfig(),
+		roomIDPattern: defaultRoomIDRegexp,
+		sessions:      make(map[string]*session),
+	}
+}
+
+// SetConfig 設置連接讀寫限制與超時，需在接受連接之前調用
+func (m *Manager) SetConfig(config Config) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.config = config.withDefaults()
+
+	pattern, err := regexp.Compile(m.config.RoomIDPattern)
+	if err != nil {
+		log.Printf("Invalid room ID pattern %q, using default %q: %v", m.config.RoomIDPattern, DefaultRoomIDPattern, err)
+		m.config.RoomIDPattern = DefaultRoomIDPattern
+		pattern = defaultRoomIDRegexp
 	}
+	m.roomIDPattern = pattern
 }
 
 // Start 啟動管理器
@@ -59,6 +94,8 @@ func (m *Manager) Start(ctx context.Context) {
 				close(client.send)
 				delete(m.clients, client)
 			}
+			m.rooms = make(map[string]map[*Client]bool)
+			m.sessions = make(map[string]*session)
 			m.mutex.Unlock()
 			return
 
@@ -66,21 +103,40 @@ func (m *Manager) Start(ctx context.Context) {
 			// 註冊新客戶端
 			m.mutex.Lock()
 			m.clients[client] = true
+			resumed := m.resumeSessionLocked(client)
+			rooms := client.roomList()
 			m.mutex.Unlock()
 			// 發送歡迎消息
 			client.send <- []byte("Hello World! Welcome to WebSocket Server")
-			log.Println("New client connected")
+			client.reply(RoomResponse{Type: MessageTypeSession, ClientID: client.ID, Rooms: rooms, Resumed: resumed})
+			log.Printf("New client %s connected", client.ID)
 
 		case client := <-m.unregister:
 			// 取消註冊客戶端
 			m.mutex.Lock()
 			if _, ok := m.clients[client]; ok {
+				m.saveSessionLocked(client)
+				m.leaveAllRooms(client)
 				delete(m.clients, client)
 				close(client.send)
 				log.Println("Client disconnected")
 			}
 			m.mutex.Unlock()
 
+		case message := <-m.roomBroadcast:
+			// 只廣播給訂閱該房間的客戶端
+			m.mutex.Lock()
+			for client := range m.rooms[message.roomID] {
+				select {
+				case client.send <- message.data:
+				default:
+					m.leaveAllRooms(client)
+					close(client.send)
+					delete(m.clients, client)
+				}
+			}
+			m.mutex.Unlock()
+
 		case message := <-m.broadcast:
 			// 廣播消息給所有客戶端
 			m.mutex.Lock()
@@ -88,6 +144,7 @@ func (m *Manager) Start(ctx context.Context) {
 				select {
 				case client.send <- message:
 				default:
+					m.leaveAllRooms(client)
 					close(client.send)
 					delete(m.clients, client)
 				}
@@ -111,13 +168,25 @@ func (m *Manager) Shutdown() {
 
 // ServeWs 處理 WebSocket 請求
 func (m *Manager) ServeWs(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	// 僅在客戶端聲明支援時才會協商壓縮
+	wsUpgrader := upgrader
+	wsUpgrader.EnableCompression = m.config.EnableCompression
+	wsUpgrader.CheckOrigin = utils.NewOriginChecker(m.config.AllowedOrigins)
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
+	if wsUpgrader.EnableCompression {
+		if err := conn.SetCompressionLevel(m.config.CompressionLevel); err != nil {
+			log.Printf("Invalid compression level %d: %v", m.config.CompressionLevel, err)
+		}
+	}
+
 	client := NewClient(m, conn)
+	client.resumeID = r.URL.Query().Get("clientId")
 	m.register <- client
 
 	// 啟動客戶端的讀寫協程
@@ -129,3 +198,10 @@ func (m *Manager) ServeWs(w http.ResponseWriter, r *http.Request) {
 func (m *Manager) BroadcastMessage(message []byte) {
 	m.broadcast <- message
 }
+
+// GetClientCount 返回當前連接的客戶端數量
+func (m *Manager) GetClientCount() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.clients)
+}
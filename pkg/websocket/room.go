@@ -0,0 +1,208 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// 房間相關命令類型
+const (
+	MessageTypeSubscribeRoom   = "SUBSCRIBE_ROOM"   // 訂閱房間
+	MessageTypeUnsubscribeRoom = "UNSUBSCRIBE_ROOM" // 取消訂閱房間
+	MessageTypeRoomSubscribed  = "ROOM_SUBSCRIBED"  // 訂閱成功回應
+	MessageTypeRoomError       = "ROOM_ERROR"       // 房間命令錯誤
+	MessageTypeSession         = "SESSION"          // 連接建立後告知客戶端ID與恢復的房間
+)
+
+// DefaultRoomIDPattern 預設的房間ID格式，例如 SG01
+const DefaultRoomIDPattern = `^[A-Z]{2}\d{2}$`
+
+var defaultRoomIDRegexp = regexp.MustCompile(DefaultRoomIDPattern)
+
+// roomBroadcastBuffer 房間廣播通道的緩衝大小
+const roomBroadcastBuffer = 256
+
+// ErrRoomBroadcastFull 房間廣播通道已滿，消息被丟棄
+var ErrRoomBroadcastFull = errors.New("room broadcast queue is full")
+
+// RoomCommand 是玩家端發送的房間命令
+type RoomCommand struct {
+	Type   string `json:"type"`
+	RoomID string `json:"roomId"`
+}
+
+// RoomResponse 是房間命令的回應
+type RoomResponse struct {
+	Type     string   `json:"type"`
+	RoomID   string   `json:"roomId,omitempty"`
+	Rooms    []string `json:"rooms,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	ClientID string   `json:"clientId,omitempty"`
+	Resumed  bool     `json:"resumed,omitempty"`
+}
+
+// roomMessage 是送往指定房間的廣播消息
+type roomMessage struct {
+	roomID string
+	data   []byte
+}
+
+// handleRoomCommand 處理房間命令，回傳是否為已知的房間命令
+func (c *Client) handleRoomCommand(cmd *RoomCommand) bool {
+	switch cmd.Type {
+	case MessageTypeSubscribeRoom:
+		if err := c.manager.ValidateRoomID(cmd.RoomID); err != nil {
+			c.reply(RoomResponse{Type: MessageTypeRoomError, RoomID: cmd.RoomID, Message: err.Error()})
+			return true
+		}
+		rooms, err := c.manager.subscribeRoom(c, cmd.RoomID)
+		if err != nil {
+			c.reply(RoomResponse{Type: MessageTypeRoomError, RoomID: cmd.RoomID, Message: err.Error()})
+			return true
+		}
+		c.reply(RoomResponse{Type: MessageTypeRoomSubscribed, RoomID: cmd.RoomID, Rooms: rooms})
+		return true
+
+	case MessageTypeUnsubscribeRoom:
+		// roomID 為空表示取消所有訂閱
+		if cmd.RoomID != "" {
+			if err := c.manager.ValidateRoomID(cmd.RoomID); err != nil {
+				c.reply(RoomResponse{Type: MessageTypeRoomError, RoomID: cmd.RoomID, Message: err.Error()})
+				return true
+			}
+		}
+		rooms := c.manager.unsubscribeRoom(c, cmd.RoomID)
+		c.reply(RoomResponse{Type: MessageTypeRoomSubscribed, RoomID: cmd.RoomID, Rooms: rooms})
+		return true
+	}
+
+	return false
+}
+
+// ValidateRoomID 檢查房間ID是否符合設定的格式
+func (m *Manager) ValidateRoomID(roomID string) error {
+	if roomID == "" {
+		return fmt.Errorf("roomId is required")
+	}
+
+	m.mutex.Lock()
+	pattern := m.roomIDPattern
+	m.mutex.Unlock()
+
+	if !pattern.MatchString(roomID) {
+		return fmt.Errorf("invalid roomId %q, expected format %s", roomID, pattern.String())
+	}
+	return nil
+}
+
+// reply 直接回應給當前客戶端，不進行廣播
+func (c *Client) reply(response RoomResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("error marshaling room response: %v", err)
+		return
+	}
+
+	select {
+	case c.send <- data:
+	default:
+		log.Println("client send buffer full, dropping room response")
+	}
+}
+
+// subscribeRoom 將客戶端加入房間，回傳客戶端目前訂閱的房間；超過訂閱上限時返回錯誤
+func (m *Manager) subscribeRoom(client *Client, roomID string) ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.joinRoomLocked(client, roomID); err != nil {
+		return client.roomList(), err
+	}
+
+	log.Printf("Client subscribed to room %s", roomID)
+	return client.roomList(), nil
+}
+
+// joinRoomLocked 在訂閱上限內將客戶端加入房間，已訂閱時不重複計算，呼叫者需持有互斥鎖
+func (m *Manager) joinRoomLocked(client *Client, roomID string) error {
+	if client.rooms[roomID] {
+		return nil
+	}
+
+	if limit := m.config.MaxSubscribersPerRoom; limit > 0 && len(m.rooms[roomID]) >= limit {
+		return fmt.Errorf("room %s is full, max %d subscribers", roomID, limit)
+	}
+	if limit := m.config.MaxRoomSubscriptions; limit > 0 && m.subscriptionCountLocked() >= limit {
+		return fmt.Errorf("server subscription limit %d reached", limit)
+	}
+
+	if _, ok := m.rooms[roomID]; !ok {
+		m.rooms[roomID] = make(map[*Client]bool)
+	}
+	m.rooms[roomID][client] = true
+	client.rooms[roomID] = true
+	return nil
+}
+
+// subscriptionCountLocked 回傳所有房間的訂閱總數，呼叫者需持有互斥鎖
+func (m *Manager) subscriptionCountLocked() int {
+	count := 0
+	for _, subscribers := range m.rooms {
+		count += len(subscribers)
+	}
+	return count
+}
+
+// unsubscribeRoom 將客戶端移出房間，roomID 為空時移出所有房間
+func (m *Manager) unsubscribeRoom(client *Client, roomID string) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if roomID == "" {
+		m.leaveAllRooms(client)
+		return client.roomList()
+	}
+
+	m.leaveRoom(client, roomID)
+	return client.roomList()
+}
+
+// leaveRoom 將客戶端移出指定房間，呼叫者需持有互斥鎖
+func (m *Manager) leaveRoom(client *Client, roomID string) {
+	if subscribers, ok := m.rooms[roomID]; ok {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(m.rooms, roomID)
+		}
+	}
+	delete(client.rooms, roomID)
+}
+
+// leaveAllRooms 將客戶端移出所有房間，呼叫者需持有互斥鎖
+func (m *Manager) leaveAllRooms(client *Client) {
+	for roomID := range client.rooms {
+		m.leaveRoom(client, roomID)
+	}
+}
+
+// roomList 回傳客戶端訂閱的房間列表，呼叫者需持有互斥鎖
+func (c *Client) roomList() []string {
+	rooms := make([]string, 0, len(c.rooms))
+	for roomID := range c.rooms {
+		rooms = append(rooms, roomID)
+	}
+	return rooms
+}
+
+// BroadcastToRoom 廣播消息給訂閱指定房間的客戶端，通道已滿時不阻塞呼叫方並返回 ErrRoomBroadcastFull
+func (m *Manager) BroadcastToRoom(roomID string, message []byte) error {
+	select {
+	case m.roomBroadcast <- &roomMessage{roomID: roomID, data: message}:
+		return nil
+	default:
+		return ErrRoomBroadcastFull
+	}
+}
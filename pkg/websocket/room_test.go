@@ -0,0 +1,266 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testEvent 測試用的房間事件
+type testEvent struct {
+	Type   string `json:"type"`
+	RoomID string `json:"roomId"`
+}
+
+// startTestServer 啟動使用指定設定的管理器與 HTTP 測試服務器
+func startTestServer(t *testing.T, config Config) (*Manager, *httptest.Server) {
+	t.Helper()
+
+	manager := NewManager()
+	manager.SetConfig(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go manager.Start(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(manager.ServeWs))
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+	})
+	return manager, server
+}
+
+// dialTestServer 連接測試服務器，clientID 不為空時帶入以恢復訂閱
+func dialTestServer(t *testing.T, server *httptest.Server, clientID string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	if clientID != "" {
+		url += "?clientId=" + clientID
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil 讀取消息直到 match 返回 true，略過非 JSON 消息；一個幀中可能合併多條以換行分隔的消息
+func readUntil(t *testing.T, conn *websocket.Conn, match func(line []byte) bool) []byte {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		for _, line := range bytes.Split(message, []byte{'\n'}) {
+			if json.Valid(line) && match(line) {
+				return line
+			}
+		}
+	}
+}
+
+// readResponse 讀取指定類型的房間命令回應
+func readResponse(t *testing.T, conn *websocket.Conn, responseType string) RoomResponse {
+	t.Helper()
+
+	var response RoomResponse
+	readUntil(t, conn, func(line []byte) bool {
+		return json.Unmarshal(line, &response) == nil && response.Type == responseType
+	})
+	return response
+}
+
+// sendCommand 發送房間命令並返回訂閱回應或錯誤回應
+func sendCommand(t *testing.T, conn *websocket.Conn, commandType, roomID string) RoomResponse {
+	t.Helper()
+
+	if err := conn.WriteJSON(RoomCommand{Type: commandType, RoomID: roomID}); err != nil {
+		t.Fatalf("write %s: %v", commandType, err)
+	}
+
+	var response RoomResponse
+	readUntil(t, conn, func(line []byte) bool {
+		return json.Unmarshal(line, &response) == nil &&
+			(response.Type == MessageTypeRoomSubscribed || response.Type == MessageTypeRoomError)
+	})
+	return response
+}
+
+// subscribe 訂閱房間並確認成功
+func subscribe(t *testing.T, conn *websocket.Conn, roomID string) {
+	t.Helper()
+
+	if response := sendCommand(t, conn, MessageTypeSubscribeRoom, roomID); response.Type != MessageTypeRoomSubscribed {
+		t.Fatalf("subscribe %s: %+v", roomID, response)
+	}
+}
+
+// broadcastEvent 廣播測試事件到房間
+func broadcastEvent(t *testing.T, manager *Manager, roomID string) {
+	t.Helper()
+
+	data, _ := json.Marshal(testEvent{Type: "EVENT", RoomID: roomID})
+	if err := manager.BroadcastToRoom(roomID, data); err != nil {
+		t.Fatalf("BroadcastToRoom(%s): %v", roomID, err)
+	}
+}
+
+// readEvent 讀取下一個測試事件
+func readEvent(t *testing.T, conn *websocket.Conn) testEvent {
+	t.Helper()
+
+	var event testEvent
+	readUntil(t, conn, func(line []byte) bool {
+		return json.Unmarshal(line, &event) == nil && event.Type == "EVENT"
+	})
+	return event
+}
+
+func TestRoomBroadcastOnlyReachesSubscribers(t *testing.T) {
+	manager, server := startTestServer(t, Config{})
+
+	playerA := dialTestServer(t, server, "")
+	playerB := dialTestServer(t, server, "")
+	subscribe(t, playerA, "SG01")
+	subscribe(t, playerB, "SG02")
+
+	// 先廣播 B 的房間；A 若錯誤收到，會在自己房間的事件之前讀到
+	broadcastEvent(t, manager, "SG02")
+	broadcastEvent(t, manager, "SG01")
+
+	if event := readEvent(t, playerA); event.RoomID != "SG01" {
+		t.Fatalf("player in SG01 received event for %s", event.RoomID)
+	}
+	if event := readEvent(t, playerB); event.RoomID != "SG02" {
+		t.Fatalf("player in SG02 received event for %s", event.RoomID)
+	}
+}
+
+func TestUnsubscribeStopsRoomEvents(t *testing.T) {
+	manager, server := startTestServer(t, Config{})
+
+	player := dialTestServer(t, server, "")
+	subscribe(t, player, "SG01")
+
+	response := sendCommand(t, player, MessageTypeUnsubscribeRoom, "SG01")
+	if response.Type != MessageTypeRoomSubscribed || len(response.Rooms) != 0 {
+		t.Fatalf("unsubscribe response = %+v, want no rooms", response)
+	}
+	subscribe(t, player, "SG02")
+
+	broadcastEvent(t, manager, "SG01")
+	broadcastEvent(t, manager, "SG02")
+
+	if event := readEvent(t, player); event.RoomID != "SG02" {
+		t.Fatalf("player received event for %s after unsubscribing", event.RoomID)
+	}
+}
+
+func TestValidateRoomID(t *testing.T) {
+	manager := NewManager()
+
+	cases := map[string]bool{
+		"SG01":  true,
+		"TW99":  true,
+		"":      false,
+		"sg01":  false,
+		"SG1":   false,
+		"SG001": false,
+		"S G1":  false,
+		"G01A":  false,
+	}
+	for roomID, valid := range cases {
+		if err := manager.ValidateRoomID(roomID); (err == nil) != valid {
+			t.Errorf("ValidateRoomID(%q) error = %v, want valid %v", roomID, err, valid)
+		}
+	}
+}
+
+func TestValidateRoomIDCustomPattern(t *testing.T) {
+	manager := NewManager()
+	manager.SetConfig(Config{RoomIDPattern: `^room-\d+$`})
+
+	if err := manager.ValidateRoomID("room-7"); err != nil {
+		t.Errorf("ValidateRoomID(room-7) = %v, want nil", err)
+	}
+	if err := manager.ValidateRoomID("SG01"); err == nil {
+		t.Error("ValidateRoomID(SG01) = nil, want error for custom pattern")
+	}
+
+	// 無法編譯的格式退回預設格式
+	manager.SetConfig(Config{RoomIDPattern: `[`})
+	if err := manager.ValidateRoomID("SG01"); err != nil {
+		t.Errorf("ValidateRoomID(SG01) with invalid pattern = %v, want default pattern", err)
+	}
+}
+
+func TestSubscribeRejectsMalformedRoomID(t *testing.T) {
+	manager, server := startTestServer(t, Config{})
+	player := dialTestServer(t, server, "")
+
+	response := sendCommand(t, player, MessageTypeSubscribeRoom, "sg-01")
+	if response.Type != MessageTypeRoomError || response.RoomID != "sg-01" {
+		t.Fatalf("subscribe response = %+v, want ROOM_ERROR for sg-01", response)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if len(manager.rooms) != 0 {
+		t.Fatalf("rooms = %v after rejected subscription, want none", manager.rooms)
+	}
+}
+
+func TestSubscribeRejectedWhenRoomIsFull(t *testing.T) {
+	_, server := startTestServer(t, Config{MaxSubscribersPerRoom: 1})
+
+	first := dialTestServer(t, server, "")
+	second := dialTestServer(t, server, "")
+	subscribe(t, first, "SG01")
+
+	if response := sendCommand(t, second, MessageTypeSubscribeRoom, "SG01"); response.Type != MessageTypeRoomError {
+		t.Fatalf("subscribe to full room = %+v, want ROOM_ERROR", response)
+	}
+	// 其他房間不受單一房間上限影響
+	subscribe(t, second, "SG02")
+
+	// 重複訂閱已加入的房間不計入上限
+	subscribe(t, first, "SG01")
+}
+
+func TestSubscribeRejectedAtTotalLimit(t *testing.T) {
+	manager, server := startTestServer(t, Config{MaxRoomSubscriptions: 2})
+
+	first := dialTestServer(t, server, "")
+	second := dialTestServer(t, server, "")
+	subscribe(t, first, "SG01")
+	subscribe(t, second, "SG02")
+
+	if response := sendCommand(t, second, MessageTypeSubscribeRoom, "SG03"); response.Type != MessageTypeRoomError {
+		t.Fatalf("subscribe past total limit = %+v, want ROOM_ERROR", response)
+	}
+
+	// 取消訂閱釋放名額後可以再次訂閱
+	sendCommand(t, first, MessageTypeUnsubscribeRoom, "SG01")
+	subscribe(t, second, "SG03")
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if count := manager.subscriptionCountLocked(); count != 2 {
+		t.Fatalf("subscription count = %d, want 2", count)
+	}
+}
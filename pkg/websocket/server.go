@@ -23,11 +23,32 @@ type Server struct {
 // NewServer 創建一個新的服務器
 func NewServer(config *config.Config) *Server {
 	return &Server{
-		wsManager: NewManager(),
+		wsManager: ProvideManager(config),
 		config:    config,
 	}
 }
 
+// ProvideManager 依應用配置創建玩家端 WebSocket 管理器
+func ProvideManager(config *config.Config) *Manager {
+	wsManager := NewManager()
+	wsManager.SetConfig(Config{
+		ReadLimit:    config.WebSocket.ReadLimit,
+		ReadTimeout:  config.WebSocket.ReadTimeout,
+		PingInterval: config.WebSocket.PingInterval,
+		WriteTimeout: config.WebSocket.WriteTimeout,
+
+		EnableCompression: config.WebSocket.EnableCompression,
+		CompressionLevel:  config.WebSocket.CompressionLevel,
+		RoomIDPattern:     config.WebSocket.RoomIDPattern,
+		ResumeGracePeriod: config.WebSocket.ResumeGracePeriod,
+		AllowedOrigins:    config.WebSocket.AllowedOrigins,
+
+		MaxSubscribersPerRoom: config.WebSocket.MaxSubscribersPerRoom,
+		MaxRoomSubscriptions:  config.WebSocket.MaxRoomSubscriptions,
+	})
+	return wsManager
+}
+
 // StartServers 啟動 API 和 WebSocket 服務器
 func (s *Server) StartServers(lc fx.Lifecycle) {
 	// 從配置中讀取端口
@@ -98,10 +119,22 @@ func (s *Server) StartServers(lc fx.Lifecycle) {
 	})
 }
 
-// Module 是 fx 模組
+// Module 是 fx 模組，提供玩家端 WebSocket 管理器；連接由 API 服務器的 /player/ws 或玩家端口的 /ws 路由轉交 ServeWs
 var Module = fx.Options(
-	fx.Provide(NewServer),
-	fx.Invoke(func(server *Server, lc fx.Lifecycle, cfg *config.Config) {
-		server.StartServers(lc)
+	fx.Provide(ProvideManager),
+	fx.Invoke(func(lc fx.Lifecycle, manager *Manager) {
+		// 管理器的生命週期跟隨應用，不使用啟動鉤子的 ctx（啟動逾時後即被取消）
+		ctx, cancel := context.WithCancel(context.Background())
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go manager.Start(ctx)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				log.Println("Player WebSocket Manager shutting down")
+				return nil
+			},
+		})
 	}),
 )
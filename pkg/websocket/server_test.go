@@ -0,0 +1,34 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"g38_lottery_service/internal/config"
+)
+
+func TestProvideManagerKeepsDefaultsForUnsetLimits(t *testing.T) {
+	manager := ProvideManager(&config.Config{})
+
+	want := DefaultConfig()
+	got := manager.config
+	if got.ReadLimit != want.ReadLimit || got.ReadTimeout != want.ReadTimeout ||
+		got.PingInterval != want.PingInterval || got.WriteTimeout != want.WriteTimeout ||
+		got.ResumeGracePeriod != want.ResumeGracePeriod {
+		t.Fatalf("config = %+v, want package defaults %+v", got, want)
+	}
+}
+
+func TestProvideManagerAppliesConfiguredLimits(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.WebSocket.ReadLimit = 1024
+	cfg.WebSocket.ReadTimeout = 20 * time.Second
+	cfg.WebSocket.PingInterval = 5 * time.Second
+	cfg.WebSocket.WriteTimeout = 3 * time.Second
+
+	got := ProvideManager(cfg).config
+	if got.ReadLimit != 1024 || got.ReadTimeout != 20*time.Second ||
+		got.PingInterval != 5*time.Second || got.WriteTimeout != 3*time.Second {
+		t.Fatalf("config = %+v, want the configured limits", got)
+	}
+}
@@ -0,0 +1,68 @@
+package websocket
+
+import (
+	"log"
+	"time"
+)
+
+// defaultResumeGracePeriod 預設斷線後保留房間訂閱的時間
+const defaultResumeGracePeriod = 30 * time.Second
+
+// session 已斷線客戶端保留的房間訂閱
+type session struct {
+	rooms     []string
+	expiresAt time.Time
+}
+
+// saveSessionLocked 保存斷線客戶端的房間訂閱，呼叫者需持有互斥鎖
+func (m *Manager) saveSessionLocked(client *Client) {
+	m.pruneSessionsLocked()
+
+	if len(client.rooms) == 0 {
+		return
+	}
+	m.sessions[client.ID] = &session{
+		rooms:     client.roomList(),
+		expiresAt: time.Now().Add(m.config.ResumeGracePeriod),
+	}
+}
+
+// resumeSessionLocked 客戶端帶入的前一個ID仍在保留期內時，沿用該ID並恢復房間訂閱，
+// 回傳是否成功恢復；呼叫者需持有互斥鎖
+func (m *Manager) resumeSessionLocked(client *Client) bool {
+	resumeID := client.resumeID
+	client.resumeID = ""
+	if resumeID == "" {
+		return false
+	}
+
+	saved, ok := m.sessions[resumeID]
+	if !ok {
+		return false
+	}
+	delete(m.sessions, resumeID)
+	if time.Now().After(saved.expiresAt) {
+		return false
+	}
+
+	client.ID = resumeID
+	for _, roomID := range saved.rooms {
+		// 斷線期間已滿的房間不再恢復
+		if err := m.joinRoomLocked(client, roomID); err != nil {
+			log.Printf("Client %s could not resume room %s: %v", client.ID, roomID, err)
+		}
+	}
+
+	log.Printf("Client %s resumed %d of %d room subscriptions", client.ID, len(client.rooms), len(saved.rooms))
+	return true
+}
+
+// pruneSessionsLocked 移除已過保留期的訂閱記錄，呼叫者需持有互斥鎖
+func (m *Manager) pruneSessionsLocked() {
+	now := time.Now()
+	for id, saved := range m.sessions {
+		if now.After(saved.expiresAt) {
+			delete(m.sessions, id)
+		}
+	}
+}
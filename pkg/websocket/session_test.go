@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// disconnect 關閉連接並等待管理器保存該客戶端的房間訂閱
+func disconnect(t *testing.T, manager *Manager, conn *websocket.Conn, clientID string) {
+	t.Helper()
+
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		manager.mutex.Lock()
+		_, saved := manager.sessions[clientID]
+		manager.mutex.Unlock()
+		if saved {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session for client %s was not saved after disconnect", clientID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResumeWithinGracePeriod(t *testing.T) {
+	manager, server := startTestServer(t, Config{ResumeGracePeriod: time.Minute})
+
+	player := dialTestServer(t, server, "")
+	first := readResponse(t, player, MessageTypeSession)
+	subscribe(t, player, "SG01")
+	disconnect(t, manager, player, first.ClientID)
+
+	// 以原客戶端ID重連，沿用ID並恢復房間訂閱
+	reconnected := dialTestServer(t, server, first.ClientID)
+	session := readResponse(t, reconnected, MessageTypeSession)
+	if !session.Resumed || session.ClientID != first.ClientID {
+		t.Fatalf("session = %+v, want resumed client %s", session, first.ClientID)
+	}
+	if !reflect.DeepEqual(session.Rooms, []string{"SG01"}) {
+		t.Fatalf("resumed rooms = %v, want [SG01]", session.Rooms)
+	}
+
+	broadcastEvent(t, manager, "SG01")
+	if event := readEvent(t, reconnected); event.RoomID != "SG01" {
+		t.Fatalf("resumed client received event for %s, want SG01", event.RoomID)
+	}
+}
+
+func TestResumeAfterGracePeriodExpires(t *testing.T) {
+	const grace = 20 * time.Millisecond
+	manager, server := startTestServer(t, Config{ResumeGracePeriod: grace})
+
+	player := dialTestServer(t, server, "")
+	first := readResponse(t, player, MessageTypeSession)
+	subscribe(t, player, "SG01")
+	disconnect(t, manager, player, first.ClientID)
+	time.Sleep(3 * grace)
+
+	// 保留期已過，重連取得新的客戶端ID且不恢復訂閱
+	reconnected := dialTestServer(t, server, first.ClientID)
+	session := readResponse(t, reconnected, MessageTypeSession)
+	if session.Resumed || session.ClientID == first.ClientID {
+		t.Fatalf("session = %+v, want a new client without resumed rooms", session)
+	}
+	if len(session.Rooms) != 0 {
+		t.Fatalf("rooms after expired resume = %v, want none", session.Rooms)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if len(manager.rooms["SG01"]) != 0 {
+		t.Fatalf("SG01 has %d subscribers after expired resume, want 0", len(manager.rooms["SG01"]))
+	}
+}
+
+func TestResumeWithUnknownClientID(t *testing.T) {
+	_, server := startTestServer(t, Config{})
+
+	player := dialTestServer(t, server, "unknown-client")
+	session := readResponse(t, player, MessageTypeSession)
+	if session.Resumed || session.ClientID == "unknown-client" {
+		t.Fatalf("session = %+v, want a new client for an unknown id", session)
+	}
+}